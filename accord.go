@@ -9,6 +9,9 @@ type Accord struct {
 	// current (one-way) latency to quorums (ms)
 	toFastQuorum float64
 	toSlowQuorum float64
+	// current round trips to quorums, whose replicas persist messages (ms)
+	fastRound float64
+	slowRound float64
 
 	// current quorums
 	fastQuorum Quorum
@@ -30,55 +33,62 @@ func (a *Accord) GetReplicas() []string {
 	return a.rs
 }
 
-func (a *Accord) FindBestQuorums(client string) {
+// Returns the number of tolerated failures `f` and the number of
+// failures `e` tolerated by the fast path.
+func (a *Accord) faults() (int, int) {
 	n := len(a.rs)
 	f := (n - 1) / 2
 	e := (n - f + 1) / 2
 	if e > f {
 		e = f
 	}
+	return f, e
+}
 
-	min := math.Inf(1)
-	slowQuorums := QuorumsOfSize(n-f, a.rs, NoFilter)
-	var slow Quorum
-	for _, q := range slowQuorums {
-		max := 0.0
-		for r := range q {
-			max = math.Max(max, a.latency.OneWayLatency(client, r))
-		}
-		if min > max {
-			slow = q.Copy()
-			min = max
+func (a *Accord) FastQuorumSize() int {
+	_, e := a.faults()
+	return len(a.rs) - e
+}
+
+// Round trip from `client` to quorum `q`, whose replicas persist the
+// message, and one-way latency to `q`.
+func (a *Accord) round(client string, q Quorum) (float64, float64) {
+	rtt, ow := 0.0, 0.0
+	for r := range q {
+		l := a.latency.OneWayLatency(client, r)
+		rtt = math.Max(rtt, 2*l+PersistDelay(r))
+		ow = math.Max(ow, l)
+	}
+	return rtt, ow
+}
+
+func (a *Accord) FindBestQuorums(client string) {
+	n := len(a.rs)
+	f, e := a.faults()
+
+	a.slowRound, a.toSlowQuorum, a.slowQuorum = math.Inf(1), math.Inf(1), nil
+	for _, q := range QuorumsOfSize(n-f, a.rs, ReachableFilter(client)) {
+		if rtt, ow := a.round(client, q); rtt < a.slowRound {
+			a.slowRound, a.toSlowQuorum, a.slowQuorum = rtt, ow, q.Copy()
 		}
 	}
-	a.toSlowQuorum = min
-	a.slowQuorum = slow
 
 	if f == e {
-		a.toFastQuorum = min
+		a.fastRound, a.toFastQuorum = a.slowRound, a.toSlowQuorum
 		a.fastQuorum = a.slowQuorum.Copy()
 		return
 	}
 
-	min = math.Inf(1)
-	fastQuorums := QuorumsOfSize(n-e, a.rs, NoFilter)
-	var fast Quorum
-	for _, q := range fastQuorums {
-		max := 0.0
-		for r := range q {
-			max = math.Max(max, a.latency.OneWayLatency(client, r))
-		}
-		if min > max {
-			fast = q.Copy()
-			min = max
+	a.fastRound, a.toFastQuorum, a.fastQuorum = math.Inf(1), math.Inf(1), nil
+	for _, q := range QuorumsOfSize(n-e, a.rs, ReachableFilter(client)) {
+		if rtt, ow := a.round(client, q); rtt < a.fastRound {
+			a.fastRound, a.toFastQuorum, a.fastQuorum = rtt, ow, q.Copy()
 		}
 	}
-	a.toFastQuorum = min
-	a.fastQuorum = fast
 }
 
 func (a *Accord) MediumPath() float64 {
-	return math.Min(6*a.toSlowQuorum, 2*a.toSlowQuorum+2*a.toFastQuorum)
+	return math.Min(3*a.slowRound, a.slowRound+a.fastRound)
 }
 
 func (a *Accord) Convoy(client string) float64 {
@@ -92,7 +102,7 @@ func (a *Accord) Convoy(client string) float64 {
 
 	// take max for each potentially coordinator of such conflicting transaction
 	for _, c := range a.rs {
-		if c == client {
+		if c == client || IsCrashed(c) || !Reachable(client, c) {
 			continue
 		}
 
@@ -122,16 +132,116 @@ func (a *Accord) Convoy(client string) float64 {
 	return convoy
 }
 
-// Assuming co-location (i.e., `client` is among `a.rs`),
-// coordinator optimization and both versions of medium path
+// Assuming coordinator optimization and both versions of medium path.
+// Clients that are not co-located with an alive replica submit their
+// commands to the closest alive replica, which coordinates them.
 func (a *Accord) Accept(client string, fast bool) float64 {
+	for _, r := range Alive(a.rs) {
+		if r == client {
+			return a.accept(client, fast)
+		}
+	}
+	coordinator := Client(client).ClosestReplica(ReachableFrom(client, a.rs), a.latency)
+	if coordinator == "" {
+		return math.Inf(1)
+	}
+	return a.accept(coordinator, fast) + 2*a.latency.OneWayLatency(client, coordinator)
+}
+
+func (a *Accord) accept(client string, fast bool) float64 {
 	a.FindBestQuorums(client)
 	if fast {
-		return 2 * a.toFastQuorum
+		return a.fastRound
 	}
 	return math.Max(a.MediumPath(), a.Convoy(client))
 }
 
+// The coordinator pre-accepts the command, replicas reply with their
+// dependencies, which the coordinator commits (after an accept round on
+// the slow path).
+func (a *Accord) Messages(client string, fast bool) []Message {
+	rs := Alive(a.rs)
+	coordinator := client
+	if !contains(rs, client) {
+		coordinator = Client(client).ClosestReplica(rs, a.latency)
+	}
+	if coordinator == "" {
+		return nil
+	}
+	ms := send(nil, client, []string{coordinator}, CommandSize)
+	ms = send(ms, coordinator, rs, CommandSize)
+	for _, r := range rs {
+		ms = send(ms, r, []string{coordinator}, DepsSize)
+	}
+	if !fast {
+		ms = send(ms, coordinator, rs, DepsSize)
+		for _, r := range rs {
+			ms = send(ms, r, []string{coordinator}, AckSize)
+		}
+	}
+	ms = send(ms, coordinator, rs, DepsSize)
+	return send(ms, coordinator, []string{client}, AckSize)
+}
+
+// Draws the pre-accept round and, on the slow path, the rounds of the
+// medium path and the delay of conflicting commands.
+func (a *Accord) Flow(client string, fast bool) []*Hop {
+	rs := Alive(a.rs)
+	coordinator := client
+	if !contains(rs, client) {
+		coordinator = Client(client).ClosestReplica(ReachableFrom(client, rs), a.latency)
+	}
+	if coordinator == "" {
+		return nil
+	}
+	f, e := a.faults()
+	req := hop(a.latency, client, coordinator, "request", 0)
+	hs := []*Hop{req}
+	round := func(label string, start float64, size int) *Hop {
+		var replies []*Hop
+		for _, r := range rs {
+			if !Reachable(coordinator, r) {
+				continue
+			}
+			m := hop(a.latency, coordinator, r, label, start)
+			if r != coordinator {
+				hs = append(hs, m)
+			}
+			replies = append(replies, hop(a.latency, r, coordinator, label+" ok", m.Arrive+PersistDelay(r)))
+		}
+		hs = append(hs, replies...)
+		return quorumLast(replies, size)
+	}
+	var last *Hop
+	n := len(a.rs)
+	if fast {
+		last = round("pre-accept", req.Arrive, n-e)
+	} else {
+		// the medium path taken by MediumPath
+		a.FindBestQuorums(coordinator)
+		labels, size := []string{"pre-accept", "accept", "commit"}, n-f
+		if 3*a.slowRound > a.slowRound+a.fastRound {
+			labels, size = labels[:2], n-e
+		}
+		for i, label := range labels {
+			if i == 0 {
+				last = round(label, req.Arrive, size)
+			} else if last != nil {
+				last = round(label, last.Arrive, n-f)
+			}
+		}
+	}
+	if last == nil {
+		return nil
+	}
+	// conflicting commands delay the commit (see Convoy)
+	if l := Round(req.Arrive + a.accept(coordinator, fast)); l > last.Arrive {
+		last = &Hop{From: coordinator, To: coordinator, Label: "conflicts", Send: last.Arrive, Arrive: l, Critical: true}
+		hs = append(hs, last)
+	}
+	return append(hs, hop(a.latency, coordinator, client, "reply", last.Arrive))
+}
+
 func (*Accord) String() string {
 	return "Accord"
 }
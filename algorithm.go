@@ -1,9 +1,20 @@
 package main
 
-import "sort"
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// cost of a client that cannot make progress (ms)
+const blockedCost = 1e9
 
 var (
 	MinWorstLatency = true
+
+	// request rates of clients, 1 for clients without weight
+	ClientWeights = map[string]float64{}
 )
 
 type Algorithm interface {
@@ -11,14 +22,62 @@ type Algorithm interface {
 	SetReplicas(rs []string)
 	GetReplicas() []string
 	Accept(client string, fast bool) float64
+	// messages sent to commit a command of `client`
+	Messages(client string, fast bool) []Message
+	// timed messages of a command of `client` on its critical path
+	Flow(client string, fast bool) []*Hop
 }
 
+func Weight(c string) float64 {
+	if w, exists := ClientWeights[c]; exists {
+		return w
+	}
+	return 1
+}
+
+// Average latency of clients `cs` weighted by their request rates. If all
+// weights are zero, clients are weighted equally.
 func Average(alg Algorithm, cs []string, fast bool) float64 {
-	l := 0.0
+	ls := make([]float64, len(cs))
+	for i, c := range cs {
+		ls[i] = alg.Accept(c, fast)
+	}
+	return average(cs, ls)
+}
+
+// Average of latencies `ls` of clients `cs` weighted by their request rates.
+func average(cs []string, ls []float64) float64 {
+	l, w, sum := 0.0, 0.0, 0.0
+	for i, c := range cs {
+		sum += ls[i]
+		// unreachable clients without requests do not count
+		if Weight(c) != 0 {
+			l += Weight(c) * ls[i]
+			w += Weight(c)
+		}
+	}
+	if w == 0 {
+		return Div(sum, float64(len(cs)))
+	}
+	return Div(l, w)
+}
+
+// Same as Average, but clients that cannot make progress are ignored and
+// each of them costs `blockedCost`, so that leaders and quorums serving
+// more clients are preferred. Returns +Inf if no client makes progress.
+func Cost(alg Algorithm, cs []string, fast bool) float64 {
+	var progressing []string
+	var ls []float64
 	for _, c := range cs {
-		l += alg.Accept(c, fast)
+		if l := alg.Accept(c, fast); !math.IsInf(l, 1) {
+			progressing = append(progressing, c)
+			ls = append(ls, l)
+		}
+	}
+	if len(progressing) == 0 {
+		return math.Inf(1)
 	}
-	return Div(l, float64(len(cs)))
+	return average(progressing, ls) + blockedCost*float64(len(cs)-len(progressing))
 }
 
 type Configuration struct {
@@ -28,24 +87,17 @@ type Configuration struct {
 }
 
 // Computes all possible configurations of `repNum` number of replicas and
-// `repNum` + `clientNum` number of clients with `repNum` being co-located
-// with servers. The resulting list is ordered by ratio between alg1 and alg2
-// in decreasing order.
-func Configs(ms []string, repNum, clientNum int, alg1, alg2 Algorithm, fast1, fast2 bool, reconf1, reconf2 func(rs, cs []string), t *LatencyTable) []*Configuration {
+// `clientNum` number of clients with 2 of them being co-located with
+// servers. Client sites of `t` never host replicas, but can be clients.
+// If `group` is not empty, at least one replica must belong to
+// the region group `group`. Only configurations where alg1 is at least 10%
+// faster than alg2 are kept, ordered by ratio between alg1 and alg2 in
+// decreasing order.
+func Configs(ms []string, repNum, clientNum int, group string, alg1, alg2 Algorithm, fast1, fast2 bool, reconf1, reconf2 func(rs, cs []string), t *LatencyTable) []*Configuration {
 	var configs []*Configuration
+	groupFilter := t.GroupFilter(group, repNum)
 	qs := QuorumsOfSize(repNum, ms, func(r string, rs []string) bool {
-		if len(rs) < repNum-1 {
-			return true
-		}
-		if _, exists := t.us[r]; exists {
-			return true
-		}
-		for _, r := range rs {
-			if _, exists := t.us[r]; exists {
-				return true
-			}
-		}
-		return false
+		return !t.IsSite(r) && groupFilter(r, rs)
 	})
 
 	for _, q := range qs {
@@ -95,3 +147,25 @@ func Configs(ms []string, repNum, clientNum int, alg1, alg2 Algorithm, fast1, fa
 	})
 	return configs
 }
+
+// Describes the first `n` configurations of `configs`.
+func ConfigsReport(t *LatencyTable, configs []*Configuration, n int) string {
+	if len(configs) == 0 {
+		return "no configuration found\n"
+	}
+	ids := func(rs []string) string {
+		s := make([]string, len(rs))
+		for i, r := range rs {
+			s[i] = t.IdOf(r)
+		}
+		return strings.Join(s, ",")
+	}
+	s := ""
+	for i, c := range configs {
+		if i == n {
+			break
+		}
+		s += fmt.Sprintf("%5.1f%% faster: replicas %v, clients %v\n", float64(c.r)/100, ids(c.rs), ids(c.cs))
+	}
+	return s
+}
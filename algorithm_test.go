@@ -0,0 +1,56 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// round trips between regions at 0, 10, 25, 45, 70 and 100ms on a line
+const line = `,a,b,c,d,e,f
+a,0,10,25,45,70,100
+b,10,0,15,35,60,90
+c,25,15,0,20,45,75
+d,45,35,20,0,25,55
+e,70,60,45,25,0,30
+f,100,90,75,55,30,0
+`
+
+// Table of regions on a line, with replicas a to e and client f.
+func lineTable(t *testing.T) (*LatencyTable, []string, []string) {
+	lt, err := ReadMatrix(strings.NewReader(line), ',')
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lt, lt.regions[:5], lt.regions
+}
+
+// algorithm with fixed client latencies
+type fixed map[string]float64
+
+func (f fixed) String() string                         { return "fixed" }
+func (f fixed) SetReplicas(rs []string)                {}
+func (f fixed) GetReplicas() []string                  { return nil }
+func (f fixed) Accept(c string, fast bool) float64     { return f[c] }
+func (f fixed) Messages(c string, fast bool) []Message { return nil }
+func (f fixed) Flow(c string, fast bool) []*Hop        { return nil }
+
+func TestAverage(t *testing.T) {
+	defer func(ws map[string]float64) {
+		ClientWeights = ws
+	}(ClientWeights)
+
+	alg := fixed{"a": 10, "b": 30, "c": math.Inf(1)}
+	ClientWeights = map[string]float64{"a": 3, "c": 0}
+	if l := Average(alg, []string{"a", "b", "c"}, true); l != 15 {
+		t.Errorf("average with an idle unreachable client is %v, want 15", l)
+	}
+	ClientWeights = map[string]float64{"a": 0, "b": 0}
+	if l := Average(alg, []string{"a", "b"}, true); l != 20 {
+		t.Errorf("average without weights is %v, want 20", l)
+	}
+	ClientWeights = map[string]float64{}
+	if l := Average(alg, []string{"a", "c"}, true); !math.IsInf(l, 1) {
+		t.Errorf("average with an unreachable client is %v, want +Inf", l)
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"strings"
+)
+
+// Aliases map provider ids, display names and nicknames of regions to
+// canonical region names, and canonical names to display names.
+type Aliases struct {
+	canonical map[string]string
+	display   map[string]string
+}
+
+func NewAliases() *Aliases {
+	return &Aliases{
+		canonical: make(map[string]string),
+		display:   make(map[string]string),
+	}
+}
+
+// Loads aliases from a file with lines of the form
+//
+//	alias canonical alias
+//	name canonical display name
+//
+// where aliases and display names extend to the end of the line.
+func LoadAliases(filename string) (*Aliases, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	a := NewAliases()
+	s := bufio.NewScanner(f)
+	for i := 1; s.Scan(); i++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kind, rest, _ := strings.Cut(line, " ")
+		canonical, value, _ := strings.Cut(strings.TrimSpace(rest), " ")
+		value = strings.TrimSpace(value)
+		if canonical == "" || value == "" {
+			return nil, errors.New(filename + ": malformed line " + line)
+		}
+		switch kind {
+		case "alias":
+			a.Add(canonical, value)
+		case "name":
+			a.display[canonical] = value
+		default:
+			return nil, errors.New(filename + ": unknown directive " + kind)
+		}
+	}
+	return a, s.Err()
+}
+
+func (a *Aliases) Add(canonical, alias string) {
+	a.canonical[strings.ToLower(alias)] = canonical
+}
+
+// Returns the canonical name of region `r` of `t`.
+func (a *Aliases) Canonical(t *LatencyTable, r string) string {
+	if a != nil {
+		for _, name := range []string{r, t.IdOf(r), t.Site(r)} {
+			if c, exists := a.canonical[strings.ToLower(name)]; exists {
+				return c
+			}
+		}
+	}
+	if strings.Contains(r, "(") {
+		// cloudping regions, e.g., "US East (N. Virginia) us-east-1"
+		return t.IdOf(r)
+	}
+	return r
+}
+
+// Renames every region of `t` to its canonical name. Regions of cloudping
+// tables keep their location as display name. Regions sharing a canonical
+// name are merged, earlier regions taking precedence.
+func (t *LatencyTable) Canonicalize(a *Aliases) {
+	for _, r := range append([]string{}, t.regions...) {
+		c := a.Canonical(t, r)
+		if c == r {
+			continue
+		}
+		if site := t.Site(r); site != r && t.names[r] == "" {
+			t.names[r] = site
+		}
+		t.Rename(r, c)
+	}
+	if a != nil {
+		for c, name := range a.display {
+			if _, exists := t.latency[c]; exists {
+				t.names[c] = name
+			}
+		}
+	}
+}
+
+// Renames region `old` to `new`. If `new` already exists, both are merged
+// and links of `new` take precedence.
+func (t *LatencyTable) Rename(old, new string) {
+	if _, exists := t.latency[old]; !exists || old == new {
+		return
+	}
+	_, merge := t.latency[new]
+
+	if merge {
+		rs := t.regions[:0]
+		for _, r := range t.regions {
+			if r != old {
+				rs = append(rs, r)
+			}
+		}
+		t.regions = rs
+	} else {
+		for i, r := range t.regions {
+			if r == old {
+				t.regions[i] = new
+			}
+		}
+		t.latency[new] = make(map[string]float64)
+	}
+
+	// links of `new` copied from `old`, which keep their estimated marks
+	copied := make(map[link]struct{})
+	for r2, l := range t.latency[old] {
+		if r2 == old {
+			r2 = new
+		}
+		if _, exists := t.latency[new][r2]; !exists {
+			t.latency[new][r2] = l
+			copied[link{new, r2}] = struct{}{}
+		}
+	}
+	delete(t.latency, old)
+	for r1, ls := range t.latency {
+		if l, exists := ls[old]; exists {
+			if _, exists := ls[new]; !exists {
+				ls[new] = l
+				copied[link{r1, new}] = struct{}{}
+			}
+			delete(ls, old)
+		}
+	}
+
+	estimated := make(map[link]struct{}, len(t.estimated))
+	for l := range t.estimated {
+		if l.r1 != old && l.r2 != old {
+			estimated[l] = struct{}{}
+			continue
+		}
+		if l.r1 == old {
+			l.r1 = new
+		}
+		if l.r2 == old {
+			l.r2 = new
+		}
+		if _, exists := copied[l]; exists {
+			estimated[l] = struct{}{}
+		}
+	}
+	t.estimated = estimated
+
+	if c, exists := t.coords[old]; exists {
+		if _, exists := t.coords[new]; !exists {
+			t.coords[new] = c
+		}
+		delete(t.coords, old)
+	}
+	if lm, exists := t.sites[old]; exists {
+		if _, exists := t.sites[new]; !exists {
+			t.sites[new] = lm
+		}
+		delete(t.sites, old)
+	}
+	if name, exists := t.names[old]; exists {
+		if _, exists := t.names[new]; !exists {
+			t.names[new] = name
+		}
+		delete(t.names, old)
+	}
+	for _, g := range t.groups {
+		for i, r := range g.Regions {
+			if r == old {
+				g.Regions[i] = new
+			}
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenameKeepsMeasuredLinks(t *testing.T) {
+	lt, err := ReadMatrix(strings.NewReader(`,virginia,us-east-1,eu-west-1,ap-south-1
+virginia,,,~80,~190
+us-east-1,,,70,
+eu-west-1,~80,70,,
+`), ',')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lt.Rename("virginia", "us-east-1")
+	if l := lt.latency["us-east-1"]["eu-west-1"]; l != 70 || lt.IsEstimated("us-east-1", "eu-west-1") {
+		t.Errorf("measured link replaced by an estimated one: %v, estimated %v", l, lt.IsEstimated("us-east-1", "eu-west-1"))
+	}
+	if lt.IsEstimated("eu-west-1", "us-east-1") {
+		t.Error("measured reverse link marked as estimated")
+	}
+	if l := lt.latency["us-east-1"]["ap-south-1"]; l != 190 || !lt.IsEstimated("us-east-1", "ap-south-1") {
+		t.Errorf("copied link is %v, estimated %v", l, lt.IsEstimated("us-east-1", "ap-south-1"))
+	}
+	if _, exists := lt.latency["virginia"]; exists || len(lt.regions) != 3 {
+		t.Errorf("regions after merge: %v", lt.regions)
+	}
+}
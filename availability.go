@@ -0,0 +1,173 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+var (
+	// probability that a region is unavailable, unless set in FailureProbabilities
+	DefaultFailureProbability = 0.001
+
+	FailureProbabilities = map[string]float64{}
+)
+
+// states less likely than this are ignored by expected latencies
+const negligibleState = 1e-9
+
+func FailureProbability(r string) float64 {
+	if p, exists := FailureProbabilities[r]; exists {
+		return p
+	}
+	return DefaultFailureProbability
+}
+
+// Parses failure probabilities given either as a single default
+// probability, a comma-separated list of `region=probability` or a file
+// with lines `region probability`. Returns the probabilities of regions and
+// the default one (DefaultFailureProbability unless `s` is a single
+// probability).
+func (t *LatencyTable) ParseFailureProbabilities(s string) (map[string]float64, float64, error) {
+	if p, err := strconv.ParseFloat(s, 64); err == nil {
+		if p < 0 || p > 1 {
+			return nil, 0, errors.New("failure probability out of [0, 1]: " + s)
+		}
+		return map[string]float64{}, p, nil
+	}
+	ps, err := t.ParseRegionValues(s)
+	if err != nil {
+		return nil, 0, err
+	}
+	for r, p := range ps {
+		if p < 0 || p > 1 {
+			return nil, 0, errors.New("failure probability of " + t.IdOf(r) + " out of [0, 1]")
+		}
+	}
+	return ps, DefaultFailureProbability, nil
+}
+
+// Availability of the quorum system of a protocol over some replicas.
+type Availability struct {
+	Name string
+	// probabilities that a slow and a fast quorum are available
+	Slow, Fast float64
+	TwoPaths   bool
+	// expected latency when a slow quorum is available
+	Latency float64
+	// expected latency when some replica is down but a slow quorum is available
+	Degraded float64
+}
+
+// Enumerates up and down states of replicas `rs` that are not crashed,
+// assuming independent failures, and computes the availability of every
+// protocol with clients `cs`. Leaders are re-elected in each state to
+// estimate latencies, while fast quorums are those of the current leader
+// or SwiftPaxos' fixed fast quorum.
+func Availabilities(t *LatencyTable, rs, cs []string) []*Availability {
+	ps := Protocols(t, rs, cs)
+	as := make([]*Availability, len(ps))
+	for i, p := range ps {
+		as[i] = &Availability{Name: p.Name, TwoPaths: p.TwoPaths}
+	}
+	latency := make([]float64, len(ps))
+	degraded := make([]float64, len(ps))
+	pLatency, pDegraded := make([]float64, len(ps)), make([]float64, len(ps))
+
+	alive := Alive(rs)
+	majority := len(rs)/2 + 1
+	for s := 0; s < 1<<len(alive); s++ {
+		prob := 1.0
+		down := Crashed.Copy()
+		for i, r := range alive {
+			if s&(1<<i) != 0 {
+				down[r] = struct{}{}
+				prob *= FailureProbability(r)
+			} else {
+				prob *= 1 - FailureProbability(r)
+			}
+		}
+		up := 0
+		for _, r := range rs {
+			if _, crashed := down[r]; !crashed {
+				up++
+			}
+		}
+		if prob == 0 || up < majority {
+			continue
+		}
+
+		for i, p := range ps {
+			as[i].Slow += prob
+			if p.FastQuorumSize != 0 && up >= p.FastQuorumSize {
+				// CURP's fast quorums contain its leader
+				if _, crashed := down[p.Leader]; !crashed {
+					as[i].Fast += prob
+				}
+			} else if p.FastQuorumSize == 0 && p.Quorum != nil {
+				fast := true
+				for r := range p.Quorum {
+					if _, crashed := down[r]; crashed {
+						fast = false
+					}
+				}
+				if fast {
+					as[i].Fast += prob
+				}
+			}
+		}
+
+		if prob < negligibleState {
+			continue
+		}
+		WithCrashed(down, func() {
+			for i, p := range Protocols(t, rs, cs) {
+				l := Average(p.Alg, cs, true)
+				if math.IsInf(l, 1) {
+					continue
+				}
+				latency[i] += prob * l
+				pLatency[i] += prob
+				if s != 0 {
+					degraded[i] += prob * l
+					pDegraded[i] += prob
+				}
+			}
+		})
+	}
+
+	for i := range as {
+		as[i].Latency, as[i].Degraded = math.Inf(1), math.Inf(1)
+		if pLatency[i] != 0 {
+			as[i].Latency = Round(latency[i] / pLatency[i])
+		}
+		if pDegraded[i] != 0 {
+			as[i].Degraded = Round(degraded[i] / pDegraded[i])
+		}
+	}
+	return as
+}
+
+// Formats probability `p` as a number of nines, e.g., "99.99%".
+func FormatAvailability(p float64) string {
+	if p >= 1 {
+		return "100%"
+	}
+	digits := int(math.Max(1, math.Floor(-math.Log10(1-p))-1))
+	return strconv.FormatFloat(100*p, 'f', digits, 64) + "%"
+}
+
+func AvailabilityReport(t *LatencyTable, rs, cs []string) string {
+	s := ""
+	for _, a := range Availabilities(t, rs, cs) {
+		fast := "N/A"
+		if a.TwoPaths {
+			fast = FormatAvailability(a.Fast)
+		}
+		s += fmt.Sprintf("%-15v slow %-10v fast %-10v latency %v, %v under failures\n",
+			a.Name, FormatAvailability(a.Slow), fast,
+			FormatLatency(a.Latency), FormatLatency(a.Degraded))
+	}
+	return s
+}
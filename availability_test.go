@@ -0,0 +1,35 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCurpFastAvailability(t *testing.T) {
+	defer func(p float64) {
+		DefaultFailureProbability = p
+	}(DefaultFailureProbability)
+
+	lt, rs, _ := lineTable(t)
+	ps, p, err := lt.ParseFailureProbabilities("0.05")
+	if err != nil || len(ps) != 0 || p != 0.05 {
+		t.Fatalf("parsed %v, %v, %v", ps, p, err)
+	}
+	if DefaultFailureProbability == p {
+		t.Fatal("parsing set the default failure probability")
+	}
+	DefaultFailureProbability = p
+
+	// every replica, or all but one that is not the leader, are up
+	want := math.Pow(0.95, 5) + 4*0.05*math.Pow(0.95, 4)
+	// a majority is up
+	slow := math.Pow(0.95, 5) + 5*0.05*math.Pow(0.95, 4) + 10*math.Pow(0.05, 2)*math.Pow(0.95, 3)
+	for _, a := range Availabilities(lt, rs, rs) {
+		if a.Name == "CURP (N²Paxos)" && math.Abs(a.Fast-want) > 1e-9 {
+			t.Errorf("CURP fast path available with probability %v, want %v", a.Fast, want)
+		}
+		if math.Abs(a.Slow-slow) > 1e-9 {
+			t.Errorf("%v available with probability %v, want %v", a.Name, a.Slow, slow)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import "math"
+
+var (
+	// leaders of leader-based protocols close a batch when it holds
+	// BatchSize commands or BatchTimeout (ms) after its first command,
+	// batches are pipelined; batching is disabled if BatchSize is at most 1
+	BatchTimeout = 0.0
+	BatchSize    = 1
+	// Poisson arrival rate of commands at the leader (commands per second)
+	ArrivalRate = 0.0
+
+	batchWait struct {
+		timeout, rate float64
+		size          int
+		wait          float64
+	}
+)
+
+func Batching() bool {
+	return BatchSize > 1 && BatchTimeout > 0
+}
+
+// Expected time (ms) a command waits at the leader for its batch to close.
+// With K(s) the number of arrivals within `s` ms after the first command of
+// a batch, the batch waits for
+//
+//	∫ E[(1 + K(s)) 1{K(s) < size-1}] ds over [0, timeout]
+//
+// in total, divided among E[min(1 + K(timeout), size)] commands.
+func BatchWait() float64 {
+	if !Batching() {
+		return 0
+	}
+	if batchWait.timeout == BatchTimeout && batchWait.size == BatchSize && batchWait.rate == ArrivalRate {
+		return Round(batchWait.wait)
+	}
+
+	rate := ArrivalRate / 1000
+	// E[(1 + K) 1{K < size-1}] and E[min(1 + K, size)] for K ~ Poisson(m)
+	moments := func(m float64) (float64, float64) {
+		open, n, p := 0.0, 0.0, 0.0
+		for k := 0; k < BatchSize-1; k++ {
+			pk := 0.0
+			if m == 0 {
+				if k == 0 {
+					pk = 1
+				}
+			} else {
+				lg, _ := math.Lgamma(float64(k + 1))
+				pk = math.Exp(float64(k)*math.Log(m) - m - lg)
+			}
+			open += float64(k+1) * pk
+			n += float64(k+1) * pk
+			p += pk
+		}
+		return open, n + float64(BatchSize)*(1-p)
+	}
+	steps := 1000
+	total, ds := 0.0, BatchTimeout/float64(steps)
+	for i := 0; i < steps; i++ {
+		open, _ := moments(rate * (float64(i) + 0.5) * ds)
+		total += open * ds
+	}
+	_, n := moments(rate * BatchTimeout)
+
+	batchWait.timeout, batchWait.size, batchWait.rate = BatchTimeout, BatchSize, ArrivalRate
+	batchWait.wait = total / n
+	return Round(batchWait.wait)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBatchWait(t *testing.T) {
+	defer func(timeout float64, size int, rate float64, p int) {
+		BatchTimeout, BatchSize, ArrivalRate, Precision = timeout, size, rate, p
+	}(BatchTimeout, BatchSize, ArrivalRate, Precision)
+	Precision = 3
+
+	BatchTimeout, BatchSize, ArrivalRate = 10, 1, 1000
+	if w := BatchWait(); w != 0 {
+		t.Errorf("wait without batching is %v", w)
+	}
+
+	// a lone command waits for the timeout
+	BatchSize, ArrivalRate = 10, 0
+	if w := BatchWait(); w != 10 {
+		t.Errorf("wait without arrivals is %v, want 10", w)
+	}
+
+	// batches fill up long before the timeout: the k-th of 10 commands
+	// arriving every 0.1ms waits (10-k)*0.1ms
+	ArrivalRate = 10000
+	if w := BatchWait(); math.Abs(w-0.45) > 0.01 {
+		t.Errorf("wait at 10000 commands/s is %v, want about 0.45", w)
+	}
+
+	// cached waits follow the settings
+	ArrivalRate = 0
+	if w := BatchWait(); w != 10 {
+		t.Errorf("wait without arrivals is %v after a change of rate, want 10", w)
+	}
+}
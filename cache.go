@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type CacheMode int
+
+const (
+	// use the disk cache if cloudping is unreachable
+	CacheDefault CacheMode = iota
+	// always scrape cloudping, never use cached data
+	CacheRefresh
+	// never scrape cloudping
+	CacheOffline
+)
+
+type cachedTable struct {
+	jsonTable
+	URL     string    `json:"url"`
+	Fetched time.Time `json:"fetched"`
+}
+
+func cacheFile(url string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	name := strings.TrimPrefix(strings.TrimPrefix(url, "https://"), "http://")
+	name = strings.NewReplacer("/", "_", ":", "_", ".", "_").Replace(strings.TrimSuffix(name, "/"))
+	return filepath.Join(dir, "flint", name+".json"), nil
+}
+
+func LoadCachedTable(url string) (*LatencyTable, error) {
+	f, err := cacheFile(url)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(f)
+	if err != nil {
+		return nil, err
+	}
+	var c cachedTable
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	t, err := c.table(f)
+	if err != nil {
+		return nil, err
+	}
+	t.fetched = c.Fetched
+	return t, nil
+}
+
+func SaveCachedTable(url string, t *LatencyTable) error {
+	f, err := cacheFile(url)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(&cachedTable{
+		jsonTable: t.jsonTable(t.regions, func(r string) string {
+			return r
+		}),
+		URL:     url,
+		Fetched: t.fetched,
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(f, data, 0644)
+}
+
+// Returns a human-readable age of the table data, or an empty string if
+// the table was not scraped from cloudping.
+func (t *LatencyTable) Age() string {
+	if t.fetched.IsZero() {
+		return ""
+	}
+	d := time.Since(t.fetched)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%d min ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%d h ago", int(d.Hours()))
+	}
+	return fmt.Sprintf("%d days ago", int(d.Hours()/24))
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+var chartColors = []string{"#4C72B0", "#DD8452", "#55A868", "#C44E52", "#8172B3", "#937860"}
+
+func svgHeader(w, h float64) string {
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" font-family="sans-serif" font-size="12">`+"\n", w, h) +
+		fmt.Sprintf(`<rect width="%.0f" height="%.0f" fill="white"/>`+"\n", w, h)
+}
+
+func svgText(x, y float64, anchor, s string) string {
+	return fmt.Sprintf(`<text x="%.1f" y="%.1f" text-anchor="%v">%v</text>`+"\n", x, y, anchor, html.EscapeString(s))
+}
+
+// Smallest of 1, 2 and 5 times a power of 10 above `v`.
+func niceMax(v float64) float64 {
+	if v <= 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+		return 1
+	}
+	p := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if m*p >= v {
+			return m * p
+		}
+	}
+	return 10 * p
+}
+
+// Vertical axis from 0 to `max` between `top` and `bottom` at `x`, with grid
+// lines up to `right`.
+func svgAxis(x, right, top, bottom, max float64, label string) string {
+	s := fmt.Sprintf(`<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="black"/>`+"\n", x, top, x, bottom)
+	for i := 0; i <= 5; i++ {
+		y := bottom - float64(i)*(bottom-top)/5
+		s += fmt.Sprintf(`<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#DDDDDD"/>`+"\n", x, y, right, y)
+		s += svgText(x-5, y+4, "end", fmt.Sprintf("%g", max*float64(i)/5))
+	}
+	s += fmt.Sprintf(`<text x="15" y="%.1f" text-anchor="middle" transform="rotate(-90 15 %.1f)">%v</text>`+"\n",
+		(top+bottom)/2, (top+bottom)/2, html.EscapeString(label))
+	return s
+}
+
+func svgLegend(x, y float64, ps []*Protocol) string {
+	s := ""
+	for i, p := range ps {
+		s += fmt.Sprintf(`<rect x="%.1f" y="%.1f" width="12" height="12" fill="%v"/>`+"\n", x, y+float64(i)*18, chartColors[i%len(chartColors)])
+		s += svgText(x+18, y+float64(i)*18+10, "start", p.Name)
+	}
+	return s
+}
+
+// Title of the latency axis of charts of the fast or slow path.
+func latencyLabel(fast bool) string {
+	if fast {
+		return "fast-path latency (ms)"
+	}
+	return "slow-path latency (ms)"
+}
+
+// Grouped bar chart of the latency of every client `cs` with protocols `ps`
+// on the fast or slow path.
+func BarChart(t *LatencyTable, ps []*Protocol, cs []string, fast bool) string {
+	const (
+		left, top, bottom = 70.0, 30.0, 80.0
+		bar, gap          = 14.0, 20.0
+	)
+	group := bar*float64(len(ps)) + gap
+	w := left + group*float64(len(cs)) + 160
+	h := 400.0
+	max := 0.0
+	for _, p := range ps {
+		for _, c := range cs {
+			if l := p.Alg.Accept(c, fast); !math.IsInf(l, 1) {
+				max = math.Max(max, l)
+			}
+		}
+	}
+	max = niceMax(max)
+	y := func(l float64) float64 {
+		return h - bottom - l/max*(h-bottom-top)
+	}
+
+	s := svgHeader(w, h)
+	s += svgAxis(left, w-160, top, h-bottom, max, latencyLabel(fast))
+	for i, c := range cs {
+		x := left + gap/2 + float64(i)*group
+		for j, p := range ps {
+			l := p.Alg.Accept(c, fast)
+			bx := x + float64(j)*bar
+			if math.IsInf(l, 1) {
+				s += svgText(bx+bar/2, h-bottom-4, "middle", "∞")
+				continue
+			}
+			s += fmt.Sprintf(`<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%v"><title>%v, %v: %0.3f ms</title></rect>`+"\n",
+				bx, y(l), bar-1, h-bottom-y(l), chartColors[j%len(chartColors)], html.EscapeString(t.Site(c)), html.EscapeString(p.Name), l)
+		}
+		cx := x + bar*float64(len(ps))/2
+		s += fmt.Sprintf(`<text x="%.1f" y="%.1f" text-anchor="end" transform="rotate(-40 %.1f %.1f)">%v</text>`+"\n",
+			cx, h-bottom+15, cx, h-bottom+15, html.EscapeString(t.Site(c)))
+	}
+	s += fmt.Sprintf(`<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="black"/>`+"\n", left, h-bottom, w-160, h-bottom)
+	s += svgLegend(w-150, top, ps)
+	return s + "</svg>\n"
+}
+
+// Cumulative distribution of latencies of clients `cs` (weighted by their
+// request rates) with protocols `ps` on the fast or slow path.
+func CDFChart(t *LatencyTable, ps []*Protocol, cs []string, fast bool) string {
+	const (
+		left, top, bottom, right = 70.0, 30.0, 50.0, 170.0
+		w, h                     = 800.0, 400.0
+	)
+	max := 0.0
+	for _, p := range ps {
+		for _, c := range cs {
+			if l := p.Alg.Accept(c, fast); !math.IsInf(l, 1) {
+				max = math.Max(max, l)
+			}
+		}
+	}
+	max = niceMax(max)
+	x := func(l float64) float64 {
+		return left + l/max*(w-left-right)
+	}
+	y := func(f float64) float64 {
+		return h - bottom - f*(h-bottom-top)
+	}
+
+	s := svgHeader(w, h)
+	s += svgAxis(left, w-right, top, h-bottom, 1, "fraction of requests")
+	s += fmt.Sprintf(`<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="black"/>`+"\n", left, h-bottom, w-right, h-bottom)
+	for i := 0; i <= 5; i++ {
+		s += svgText(x(max*float64(i)/5), h-bottom+15, "middle", fmt.Sprintf("%g", max*float64(i)/5))
+	}
+	s += svgText((left+w-right)/2, h-10, "middle", latencyLabel(fast))
+
+	total := 0.0
+	for _, c := range cs {
+		total += Weight(c)
+	}
+	for i, p := range ps {
+		type point struct{ l, w float64 }
+		var pts []point
+		for _, c := range cs {
+			w := Weight(c)
+			if total == 0 {
+				w = 1
+			}
+			if l := p.Alg.Accept(c, fast); !math.IsInf(l, 1) {
+				pts = append(pts, point{l, w})
+			}
+		}
+		sort.Slice(pts, func(i, j int) bool {
+			return pts[i].l < pts[j].l
+		})
+		sum := total
+		if sum == 0 {
+			sum = float64(len(cs))
+		}
+		path, f := fmt.Sprintf("M %.1f %.1f", x(0), y(0)), 0.0
+		for _, pt := range pts {
+			path += fmt.Sprintf(" H %.1f", x(pt.l))
+			f += pt.w / sum
+			path += fmt.Sprintf(" V %.1f", y(f))
+		}
+		path += fmt.Sprintf(" H %.1f", x(max))
+		s += fmt.Sprintf(`<path d="%v" fill="none" stroke="%v" stroke-width="2"/>`+"\n", path, chartColors[i%len(chartColors)])
+	}
+	s += svgLegend(w-right+20, top, ps)
+	return s + "</svg>\n"
+}
+
+// Heatmap of the speedup (%) of every protocol `ps` over protocol `base`
+// for every client `cs` on the fast or slow path.
+func SpeedupHeatmap(t *LatencyTable, ps []*Protocol, base *Protocol, cs []string, fast bool) string {
+	const (
+		left, top, cw, ch = 160.0, 60.0, 110.0, 24.0
+	)
+	w := left + cw*float64(len(ps)) + 20
+	h := top + ch*float64(len(cs)) + 20
+
+	s := svgHeader(w, h)
+	path := "fast"
+	if !fast {
+		path = "slow"
+	}
+	s += svgText(10, 20, "start", "speedup over "+base.Name+" on the "+path+" path (%)")
+	for j, p := range ps {
+		s += svgText(left+cw*float64(j)+cw/2, top-8, "middle", p.Name)
+	}
+	for i, c := range cs {
+		cy := top + ch*float64(i)
+		s += svgText(left-8, cy+ch/2+4, "end", t.Site(c))
+		b := base.Alg.Accept(c, fast)
+		for j, p := range ps {
+			cx := left + cw*float64(j)
+			l := p.Alg.Accept(c, fast)
+			color, label := "#EEEEEE", "∞"
+			if !math.IsInf(l, 1) {
+				f := speedup(l, b)
+				label = fmt.Sprintf("%+.0f%%", f)
+				// from red (slower) through white to green (faster)
+				a := math.Min(math.Abs(f)/50, 1)
+				if f >= 0 {
+					color = fmt.Sprintf("rgb(%.0f,%.0f,%.0f)", 255-a*170, 255-a*66, 255-a*170)
+				} else {
+					color = fmt.Sprintf("rgb(%.0f,%.0f,%.0f)", 255-a*59, 255-a*177, 255-a*173)
+				}
+			}
+			s += fmt.Sprintf(`<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%v" stroke="white"/>`+"\n", cx, cy, cw, ch, color)
+			s += svgText(cx+cw/2, cy+ch/2+4, "middle", label)
+		}
+	}
+	return s + "</svg>\n"
+}
+
+// Writes bars.svg, cdf.svg and speedup.svg into `dir` comparing protocols
+// over replicas `rs` for clients `cs` on the fast path, and bars-slow.svg,
+// cdf-slow.svg and speedup-slow.svg on the slow path. Speedups are relative
+// to protocol `base` (Paxos if empty).
+func ExportCharts(t *LatencyTable, rs, cs []string, base, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	ps := Protocols(t, rs, cs)
+	var b *Protocol
+	for _, p := range ps {
+		if p.Name == base || (base == "" && p.Name == "Paxos") {
+			b = p
+		}
+	}
+	if b == nil {
+		return errors.New(fmt.Sprintf("unknown protocol %v", base))
+	}
+	charts := map[string]string{
+		"bars.svg":         BarChart(t, ps, cs, true),
+		"cdf.svg":          CDFChart(t, ps, cs, true),
+		"speedup.svg":      SpeedupHeatmap(t, ps, b, cs, true),
+		"bars-slow.svg":    BarChart(t, ps, cs, false),
+		"cdf-slow.svg":     CDFChart(t, ps, cs, false),
+		"speedup-slow.svg": SpeedupHeatmap(t, ps, b, cs, false),
+	}
+	for name, chart := range charts {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(chart), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
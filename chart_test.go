@@ -0,0 +1,24 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestExportCharts(t *testing.T) {
+	lt, rs, cs := lineTable(t)
+	dir := t.TempDir()
+	if err := ExportCharts(lt, rs, cs, "Raft", dir); err == nil {
+		t.Error("no error with an unknown baseline")
+	}
+	if fs, _ := os.ReadDir(dir); len(fs) != 0 {
+		t.Errorf("wrote %v charts with an unknown baseline", len(fs))
+	}
+
+	if err := ExportCharts(lt, rs, cs, "", dir); err != nil {
+		t.Fatal(err)
+	}
+	if fs, _ := os.ReadDir(dir); len(fs) != 6 {
+		t.Errorf("wrote %v charts, want 6", len(fs))
+	}
+}
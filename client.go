@@ -1,6 +1,14 @@
 package main
 
-import "math"
+import (
+	"bufio"
+	"errors"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type Client string
 
@@ -18,3 +26,139 @@ func (c Client) ClosestReplica(rs []string, t *LatencyTable) string {
 
 	return closest
 }
+
+// Declares `name` as a client site, i.e., a client population that cannot
+// host replicas, with `lastMile` (ms) of one-way latency added to every
+// message it sends or receives. Its latencies to regions are set as links.
+func (t *LatencyTable) AddSite(name string, lastMile float64) {
+	if _, exists := t.latency[name]; !exists {
+		t.addRegion(name)
+	}
+	t.sites[name] = lastMile
+}
+
+func (t *LatencyTable) IsSite(r string) bool {
+	_, exists := t.sites[r]
+	return exists
+}
+
+// Returns regions that can host replicas.
+func (t *LatencyTable) ReplicaRegions() []string {
+	var rs []string
+	for _, r := range t.regions {
+		if !t.IsSite(r) {
+			rs = append(rs, r)
+		}
+	}
+	return rs
+}
+
+// Loads client sites from a file with lines of the form
+//
+//	site name [last-mile latency]
+//	name region round-trip latency
+func (t *LatencyTable) LoadSites(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		data := strings.Fields(s.Text())
+		if len(data) == 0 || strings.HasPrefix(data[0], "#") {
+			continue
+		}
+		if data[0] == "site" {
+			if len(data) < 2 || len(data) > 3 {
+				return errors.New(filename + ": malformed site " + s.Text())
+			}
+			lastMile := 0.0
+			if len(data) == 3 {
+				d, err := time.ParseDuration(data[2])
+				if err != nil {
+					return err
+				}
+				lastMile = float64(d) / float64(time.Millisecond)
+			}
+			t.AddSite(data[1], lastMile)
+			continue
+		}
+		if len(data) != 3 {
+			continue
+		}
+		if !t.IsSite(data[0]) {
+			return errors.New(filename + ": undeclared site " + data[0])
+		}
+		r, exists := t.Region(data[1])
+		if !exists {
+			return errors.New(filename + ": unknown region " + data[1])
+		}
+		d, err := time.ParseDuration(data[2])
+		if err != nil {
+			return err
+		}
+		t.latency[data[0]][r] = float64(d) / float64(time.Millisecond)
+		t.latency[r][data[0]] = float64(d) / float64(time.Millisecond)
+	}
+	return s.Err()
+}
+
+// Parses client weights given either as a comma-separated list of
+// `region=weight` or as a file with lines `region weight`.
+func (t *LatencyTable) ParseWeights(s string) (map[string]float64, error) {
+	ws, err := t.ParseRegionValues(s)
+	if err != nil {
+		return nil, err
+	}
+	for r, w := range ws {
+		if w < 0 {
+			return nil, errors.New("negative weight of " + t.IdOf(r))
+		}
+	}
+	return ws, nil
+}
+
+// Parses values of regions given either as a comma-separated list of
+// `region=value` or as a file with lines `region value`.
+func (t *LatencyTable) ParseRegionValues(s string) (map[string]float64, error) {
+	var pairs [][]string
+	if strings.Contains(s, "=") {
+		for _, p := range strings.Split(s, ",") {
+			if r, v, found := strings.Cut(p, "="); found {
+				pairs = append(pairs, []string{strings.TrimSpace(r), strings.TrimSpace(v)})
+			}
+		}
+	} else {
+		f, err := os.Open(s)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			data := strings.Fields(sc.Text())
+			if len(data) == 2 && !strings.HasPrefix(data[0], "#") {
+				pairs = append(pairs, data)
+			}
+		}
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	vs := map[string]float64{}
+	for _, p := range pairs {
+		r, exists := t.Region(p[0])
+		if !exists {
+			return nil, errors.New("unknown region " + p[0])
+		}
+		v, err := strconv.ParseFloat(p[1], 64)
+		if err != nil {
+			return nil, err
+		}
+		vs[r] = v
+	}
+	return vs, nil
+}
@@ -1,5 +1,53 @@
 package main
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
 var (
 	cloudping = "https://www.cloudping.co/"
+
+	cloudpingPercentiles = []string{"p_10", "p_25", "p_50", "p_75", "p_90", "p_98", "p_99"}
+	cloudpingTimeframes  = []string{"1D", "1W", "1M", "1Y"}
+
+	cloudpingCache = map[string]*LatencyTable{}
 )
+
+// Returns the URL of the cloudping grid for the given percentile and
+// timeframe. Both "p90" and "p_90" are accepted as percentiles.
+func CloudpingURL(percentile, timeframe string) (string, error) {
+	if percentile == "" && timeframe == "" {
+		return cloudping, nil
+	}
+	if percentile == "" {
+		percentile = "p_50"
+	}
+	if timeframe == "" {
+		timeframe = "1D"
+	}
+
+	p := strings.ToLower(percentile)
+	if !strings.HasPrefix(p, "p_") {
+		p = "p_" + strings.TrimPrefix(p, "p")
+	}
+	if !contains(cloudpingPercentiles, p) {
+		return "", errors.New(fmt.Sprintf("unknown percentile %v, expected one of %v", percentile, cloudpingPercentiles))
+	}
+	tf := strings.ToUpper(timeframe)
+	if !contains(cloudpingTimeframes, tf) {
+		return "", errors.New(fmt.Sprintf("unknown timeframe %v, expected one of %v", timeframe, cloudpingTimeframes))
+	}
+
+	return cloudping + "grid/" + p + "/timeframe/" + tf, nil
+}
+
+func contains(ss []string, s string) bool {
+	for _, z := range ss {
+		if z == s {
+			return true
+		}
+	}
+	return false
+}
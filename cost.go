@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	// message sizes (bytes): messages carrying the command, acknowledgments
+	// and messages carrying dependencies
+	CommandSize = 256
+	AckSize     = 64
+	DepsSize    = 128
+
+	// egress price ($/GB) between regions, unless set for the pair of regions
+	// or for the source region
+	DefaultEgressPrice = 0.02
+
+	EgressPrices       = map[link]float64{}
+	RegionEgressPrices = map[string]float64{}
+)
+
+type Message struct {
+	From, To string
+	Size     int
+}
+
+// Appends messages of `size` bytes sent by `from` to every region of `to`
+// except itself.
+func send(ms []Message, from string, to []string, size int) []Message {
+	for _, r := range to {
+		if r != from {
+			ms = append(ms, Message{From: from, To: r, Size: size})
+		}
+	}
+	return ms
+}
+
+// Price ($/GB) of traffic from `r1` to `r2`. Traffic within a region is free.
+func EgressPrice(r1, r2 string) float64 {
+	if r1 == r2 {
+		return 0
+	}
+	if p, exists := EgressPrices[link{r1, r2}]; exists {
+		return p
+	}
+	if p, exists := RegionEgressPrices[r1]; exists {
+		return p
+	}
+	return DefaultEgressPrice
+}
+
+// Loads egress prices given either as a single default price or as a file
+// with lines `src dst price` or `src price`.
+func (t *LatencyTable) LoadEgressPrices(s string) error {
+	if p, err := strconv.ParseFloat(s, 64); err == nil {
+		DefaultEgressPrice = p
+		return nil
+	}
+	f, err := os.Open(s)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		data := strings.Fields(sc.Text())
+		if len(data) < 2 || len(data) > 3 || strings.HasPrefix(data[0], "#") {
+			continue
+		}
+		p, err := strconv.ParseFloat(data[len(data)-1], 64)
+		if err != nil {
+			return errors.New(s + ": " + err.Error())
+		}
+		rs, unknown := t.ParseRegions(strings.Join(data[:len(data)-1], ","))
+		if len(unknown) != 0 {
+			return errors.New(s + ": unknown region " + unknown[0])
+		}
+		if len(rs) == 1 {
+			RegionEgressPrices[rs[0]] = p
+		} else {
+			EgressPrices[link{rs[0], rs[1]}] = p
+		}
+	}
+	return sc.Err()
+}
+
+// Parses message sizes given as `command=bytes,ack=bytes,deps=bytes`.
+func ParseMessageSizes(s string) error {
+	for _, p := range strings.Split(s, ",") {
+		kind, v, found := strings.Cut(p, "=")
+		if !found {
+			return errors.New("invalid message size " + p)
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || size < 0 {
+			return errors.New("invalid message size " + p)
+		}
+		switch strings.TrimSpace(kind) {
+		case "command":
+			CommandSize = size
+		case "ack":
+			AckSize = size
+		case "deps":
+			DepsSize = size
+		default:
+			return errors.New("unknown message kind " + kind)
+		}
+	}
+	return nil
+}
+
+// Traffic of one command of protocol `alg` averaged over clients `cs`
+// weighted by their request rates: number of messages, bytes and egress
+// price ($) of a million commands.
+func Traffic(alg Algorithm, cs []string, fast bool) (float64, float64, float64) {
+	w := 0.0
+	for _, c := range cs {
+		w += Weight(c)
+	}
+	msgs, bytes, price := 0.0, 0.0, 0.0
+	for _, c := range cs {
+		weight := Weight(c)
+		if w == 0 {
+			weight = 1
+		}
+		for _, m := range alg.Messages(c, fast) {
+			msgs += weight
+			bytes += weight * float64(m.Size)
+			price += weight * float64(m.Size) / 1e3 * EgressPrice(m.From, m.To)
+		}
+	}
+	if w == 0 {
+		w = float64(len(cs))
+	}
+	if w == 0 {
+		return 0, 0, 0
+	}
+	return msgs / w, bytes / w, price / w
+}
+
+// Formats `b` bytes with a decimal unit, as egress is priced per GB.
+func FormatBytes(b float64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	i := 0
+	for b >= 1000 && i < len(units)-1 {
+		b /= 1000
+		i++
+	}
+	return strconv.FormatFloat(b, 'f', 1, 64) + " " + units[i]
+}
+
+func CostReport(t *LatencyTable, rs, cs []string) string {
+	s := fmt.Sprintf("%-15v %8v %6v %9v %9v %8v\n", "", "latency", "msgs", "per cmd", "per 1M", "$ per 1M")
+	for _, p := range Protocols(t, rs, cs) {
+		msgs, bytes, price := Traffic(p.Alg, cs, true)
+		s += fmt.Sprintf("%-15v %8v %6.1f %9v %9v %8.4f\n", p.Name,
+			strings.TrimSpace(FormatLatency(Average(p.Alg, cs, true))),
+			msgs, FormatBytes(bytes), FormatBytes(bytes*1e6), price)
+	}
+	return s
+}
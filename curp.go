@@ -25,12 +25,15 @@ func (c *CurpN2Paxos) GetReplicas() []string {
 }
 
 func (c *CurpN2Paxos) Accept(client string, fast bool) float64 {
+	if c.leader == "" || IsCrashed(c.leader) {
+		return math.Inf(1)
+	}
 	if fast {
-		size := (3*len(c.rs))/4 + 1
-		if (3*len(c.rs))%4 == 0 {
-			size--
-		}
+		size := c.FastQuorumSize()
 		filter := func(a string, rs []string) bool {
+			if IsCrashed(a) || !Reachable(client, a) {
+				return false
+			}
 			if len(rs) == size-1 {
 				if a == c.leader {
 					return true
@@ -49,7 +52,8 @@ func (c *CurpN2Paxos) Accept(client string, fast bool) float64 {
 		for _, q := range fastQs {
 			qm := 0.0
 			for r := range q {
-				l := Mul(c.latency.OneWayLatency(client, r), 2)
+				// witnesses do not persist commands
+				l := Round(Mul(c.latency.OneWayLatency(client, r), 2) + ProcessingDelay(r))
 				qm = math.Max(qm, l)
 			}
 			m = math.Min(m, qm)
@@ -62,27 +66,104 @@ func (c *CurpN2Paxos) Accept(client string, fast bool) float64 {
 	return n2paxos.Accept(client, fast)
 }
 
+// The client sends the command to every replica, which record it and reply,
+// while the leader replicates it with N²Paxos. The slow path waits for the
+// reply of N²Paxos.
+func (c *CurpN2Paxos) Messages(client string, fast bool) []Message {
+	if c.leader == "" || IsCrashed(c.leader) {
+		return nil
+	}
+	rs := Alive(c.rs)
+	ms := send(nil, client, rs, CommandSize)
+	for _, r := range rs {
+		ms = send(ms, r, []string{client}, AckSize)
+	}
+	ms = send(ms, c.leader, rs, CommandSize)
+	for _, r := range rs {
+		ms = send(ms, r, rs, AckSize)
+	}
+	if !fast {
+		ms = send(ms, Client(client).ClosestReplica(rs, c.latency), []string{client}, AckSize)
+	}
+	return ms
+}
+
+// The fast path is drawn unless the slow path is faster.
+func (c *CurpN2Paxos) Flow(client string, fast bool) []*Hop {
+	if c.leader == "" || IsCrashed(c.leader) {
+		return nil
+	}
+	n2paxos := NewPaxos(c.rs, c.latency, true)
+	n2paxos.leader = c.leader
+	slow := n2paxos.Flow(client, false)
+	if !fast {
+		return slow
+	}
+
+	var hs, leader, others []*Hop
+	for _, r := range c.rs {
+		if !ReachableFilter(client)(r, nil) {
+			continue
+		}
+		req := hop(c.latency, client, r, "request", 0)
+		reply := hop(c.latency, r, client, "record", req.Arrive+ProcessingDelay(r))
+		hs = append(hs, req)
+		if r == c.leader {
+			leader = append(leader, reply)
+		} else {
+			others = append(others, reply)
+		}
+	}
+	if len(leader) == 0 {
+		return slow
+	}
+	var last *Hop
+	if c.FastQuorumSize() == 1 {
+		last = quorumLast(leader, 1)
+	} else if last = quorumLast(others, c.FastQuorumSize()-1); last == nil {
+		return slow
+	} else if leader[0].Arrive > last.Arrive {
+		last.Critical = false
+		leader[0].Critical = true
+		last = leader[0]
+	}
+	if last.Arrive > flowEnd(slow, client) {
+		return slow
+	}
+	hs = append(hs, leader...)
+	return append(hs, others...)
+}
+
+// Fast quorums contain the leader.
+func (c *CurpN2Paxos) FastQuorumSize() int {
+	size := (3*len(c.rs))/4 + 1
+	if (3*len(c.rs))%4 == 0 {
+		size--
+	}
+	return size
+}
+
 func (c *CurpN2Paxos) SetAverageBestLeader(cs []string) (string, float64) {
 	min := math.Inf(1)
 	leader := ""
 
-	for _, r := range c.rs {
+	for _, r := range Alive(c.rs) {
 		c.leader = r
 		if MinWorstLatency {
-			l := Average(c, cs, false)
+			l := Cost(c, cs, false)
 			if l < min {
 				min = l
 				leader = r
 			} else if l == min {
-				l1 := Average(c, cs, true)
+				l1 := Cost(c, cs, true)
 				c.leader = leader
-				l2 := Average(c, cs, true)
+				l2 := Cost(c, cs, true)
 				if l1 < l2 {
 					leader = r
 				}
 			}
 		} else {
-			l := Average(c, cs, true)
+			l := Cost(c, cs, true)
 			if l < min {
 				min = l
 				leader = r
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	// delays (ms) of replicas handling a message and persisting it, unless
+	// set in ProcessingDelays and FsyncDelays
+	DefaultProcessingDelay = 0.0
+	DefaultFsyncDelay      = 0.0
+
+	ProcessingDelays = map[string]float64{}
+	FsyncDelays      = map[string]float64{}
+
+	// used to compute contributions of delays
+	ignoreProcessing, ignoreFsync bool
+)
+
+func ProcessingDelay(r string) float64 {
+	if ignoreProcessing {
+		return 0
+	}
+	if d, exists := ProcessingDelays[r]; exists {
+		return d
+	}
+	return DefaultProcessingDelay
+}
+
+func FsyncDelay(r string) float64 {
+	if ignoreFsync {
+		return 0
+	}
+	if d, exists := FsyncDelays[r]; exists {
+		return d
+	}
+	return DefaultFsyncDelay
+}
+
+// Delay of replica `r` before replying to a message it has to persist.
+func PersistDelay(r string) float64 {
+	return ProcessingDelay(r) + FsyncDelay(r)
+}
+
+// Loads delays of replicas from a file with lines
+// `region processing-delay fsync-delay`, e.g., `us-east-1 50us 2ms`.
+func (t *LatencyTable) LoadDelays(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		data := strings.Fields(s.Text())
+		if len(data) != 3 || strings.HasPrefix(data[0], "#") {
+			continue
+		}
+		r, exists := t.Region(data[0])
+		if !exists {
+			return errors.New(filename + ": unknown region " + data[0])
+		}
+		p, err := time.ParseDuration(data[1])
+		if err != nil {
+			return errors.New(filename + ": " + err.Error())
+		}
+		d, err := time.ParseDuration(data[2])
+		if err != nil {
+			return errors.New(filename + ": " + err.Error())
+		}
+		ProcessingDelays[r] = float64(p) / float64(time.Millisecond)
+		FsyncDelays[r] = float64(d) / float64(time.Millisecond)
+	}
+	return s.Err()
+}
+
+func HasDelays() bool {
+	return DefaultProcessingDelay != 0 || DefaultFsyncDelay != 0 ||
+		len(ProcessingDelays) != 0 || len(FsyncDelays) != 0
+}
+
+// Contributions of processing and fsync delays to the average latency of
+// `alg` for clients `cs`, with its current leader and quorums.
+func DelayContributions(alg Algorithm, cs []string, fast bool) (float64, float64) {
+	l := Average(alg, cs, fast)
+	defer func() {
+		ignoreProcessing, ignoreFsync = false, false
+	}()
+	ignoreFsync = true
+	lp := Average(alg, cs, fast)
+	ignoreProcessing = true
+	l0 := Average(alg, cs, fast)
+	return Round(lp - l0), Round(l - lp)
+}
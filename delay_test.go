@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestAccordPersistsOncePerRound(t *testing.T) {
+	defer func(f float64) {
+		DefaultFsyncDelay = f
+	}(DefaultFsyncDelay)
+
+	// a reaches its closest quorum {a, b, c} in a 25ms round trip, and
+	// with five replicas the fast and slow quorums coincide: the medium
+	// path takes two rounds
+	lt, rs, _ := lineTable(t)
+	a := NewAccord(rs, lt)
+	DefaultFsyncDelay = 0
+	if l, m := a.Accept("a", true), a.MediumPath(); l != 25 || m != 50 {
+		t.Fatalf("fast and medium paths without fsyncs are %v and %v, want 25 and 50", l, m)
+	}
+	DefaultFsyncDelay = 4
+	if l, m := a.Accept("a", true), a.MediumPath(); l != 29 || m != 58 {
+		t.Errorf("fast and medium paths with 4ms fsyncs are %v and %v, want 29 and 58", l, m)
+	}
+
+	processing, fsync := DelayContributions(a, rs, true)
+	if processing != 0 || fsync != 4 || ignoreFsync || ignoreProcessing {
+		t.Errorf("contributions %v, %v, ignored delays %v, %v", processing, fsync, ignoreProcessing, ignoreFsync)
+	}
+}
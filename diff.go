@@ -0,0 +1,173 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+type LinkChange struct {
+	// region ids
+	R1, R2 string
+	// round-trip latencies (ms), NaN for missing links
+	Old, New float64
+}
+
+// Compares round-trip latencies of links between regions with the same
+// ids in `t1` and `t2`. Changes of at most `threshold` ms are ignored.
+// The result is sorted by decreasing absolute change.
+func DiffLinks(t1, t2 *LatencyTable, threshold float64) []LinkChange {
+	ids := []string{}
+	seen := map[string]struct{}{}
+	for _, t := range []*LatencyTable{t1, t2} {
+		for _, r := range t.regions {
+			if _, exists := seen[t.IdOf(r)]; !exists {
+				seen[t.IdOf(r)] = struct{}{}
+				ids = append(ids, t.IdOf(r))
+			}
+		}
+	}
+
+	rtt := func(t *LatencyTable, id1, id2 string) float64 {
+		r1, ok1 := t.Region(id1)
+		r2, ok2 := t.Region(id2)
+		if !ok1 || !ok2 || !t.HasLink(r1, r2) {
+			return math.NaN()
+		}
+		return 2 * t.oneWay(r1, r2)
+	}
+
+	var cs []LinkChange
+	for i, id1 := range ids {
+		for _, id2 := range ids[i+1:] {
+			c := LinkChange{R1: id1, R2: id2, Old: rtt(t1, id1, id2), New: rtt(t2, id1, id2)}
+			if math.IsNaN(c.Old) && math.IsNaN(c.New) {
+				continue
+			}
+			if math.IsNaN(c.Old) || math.IsNaN(c.New) || math.Abs(c.New-c.Old) > threshold {
+				cs = append(cs, c)
+			}
+		}
+	}
+
+	delta := func(c LinkChange) float64 {
+		if math.IsNaN(c.Old) || math.IsNaN(c.New) {
+			return math.Inf(1)
+		}
+		return math.Abs(c.New - c.Old)
+	}
+	sort.SliceStable(cs, func(i, j int) bool {
+		return delta(cs[i]) > delta(cs[j])
+	})
+	return cs
+}
+
+func (c LinkChange) String() string {
+	switch {
+	case math.IsNaN(c.Old):
+		return fmt.Sprintf("%v <-> %v added (%vms)", c.R1, c.R2, FormatMs(c.New))
+	case math.IsNaN(c.New):
+		return fmt.Sprintf("%v <-> %v removed (%vms)", c.R1, c.R2, FormatMs(c.Old))
+	}
+	return fmt.Sprintf("%v <-> %v %vms -> %vms%v", c.R1, c.R2, FormatMs(c.Old), FormatMs(c.New), relativeChange(c.Old, c.New))
+}
+
+// Formats the relative change from `old` to `new`, if it is defined.
+func relativeChange(old, new float64) string {
+	if old == 0 || math.IsInf(old, 0) || math.IsInf(new, 0) {
+		return ""
+	}
+	return fmt.Sprintf(" (%+.1f%%)", 100*(new-old)/old)
+}
+
+func quorumIds(t *LatencyTable, q Quorum) string {
+	if q == nil {
+		return "N/A"
+	}
+	var ids []string
+	for r := range q {
+		ids = append(ids, t.IdOf(r))
+	}
+	sort.Strings(ids)
+	return "{" + strings.Join(ids, " ") + "}"
+}
+
+// Describes how per-link latencies and, for every protocol over replicas
+// `rs` with clients `cs` (region ids), chosen leaders and quorums and
+// per-client latencies change from `t1` to `t2`.
+func Diff(t1, t2 *LatencyTable, rs, cs []string, threshold float64) (string, error) {
+	s := "links:\n"
+	changes := DiffLinks(t1, t2, threshold)
+	for _, c := range changes {
+		s += "  " + c.String() + "\n"
+	}
+	if len(changes) == 0 {
+		s += "  no changes\n"
+	}
+	if len(rs) == 0 || len(cs) == 0 {
+		return s, nil
+	}
+
+	resolve := func(t *LatencyTable, ids []string) ([]string, error) {
+		regions, unknown := t.ParseRegions(strings.Join(ids, ","))
+		if len(unknown) != 0 {
+			return nil, errors.New("unknown regions " + strings.Join(unknown, ", "))
+		}
+		return regions, nil
+	}
+	rs1, err := resolve(t1, rs)
+	if err != nil {
+		return "", err
+	}
+	cs1, err := resolve(t1, cs)
+	if err != nil {
+		return "", err
+	}
+	rs2, err := resolve(t2, rs)
+	if err != nil {
+		return "", err
+	}
+	cs2, err := resolve(t2, cs)
+	if err != nil {
+		return "", err
+	}
+
+	ps1, ps2 := Protocols(t1, rs1, cs1), Protocols(t2, rs2, cs2)
+	change := func(old, new float64) string {
+		if old == new {
+			return fmt.Sprintf("%0.3f", old)
+		}
+		return fmt.Sprintf("%0.3f -> %0.3f%v", old, new, relativeChange(old, new))
+	}
+	for i, p1 := range ps1 {
+		p2 := ps2[i]
+		s += "\n" + p1.Name + ":\n"
+		if p1.Leader != "<leaderless>" {
+			l1, l2 := t1.IdOf(p1.Leader), t2.IdOf(p2.Leader)
+			if l1 == l2 {
+				s += "  leader: " + l1 + "\n"
+			} else {
+				s += "  leader: " + l1 + " -> " + l2 + "\n"
+			}
+		}
+		if p1.Quorum != nil {
+			q1, q2 := quorumIds(t1, p1.Quorum), quorumIds(t2, p2.Quorum)
+			if q1 == q2 {
+				s += "  quorum: " + q1 + "\n"
+			} else {
+				s += "  quorum: " + q1 + " -> " + q2 + "\n"
+			}
+		}
+		s += "  average: " + change(Average(p1.Alg, cs1, true), Average(p2.Alg, cs2, true)) + "\n"
+		for j, c := range cs1 {
+			s += "  " + t1.IdOf(c) + ": " + change(Average(p1.Alg, []string{c}, true), Average(p2.Alg, []string{cs2[j]}, true))
+			if p1.TwoPaths {
+				s += ", slow " + change(Average(p1.Alg, []string{c}, false), Average(p2.Alg, []string{cs2[j]}, false))
+			}
+			s += "\n"
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// failure-detection timeout (ms) after which a silent leader is replaced
+var FailureTimeout = 1000.0
+
+// Failover estimates the unavailability window of a leader-based protocol
+// after the crash of its leader: the crash is detected, the new leader
+// runs phase 1 over a majority, recovers commands that might have been
+// committed on the fast path (if any) and commits the first new command.
+type Failover struct {
+	Name      string
+	Old, New  string
+	Detection float64
+	Election  float64
+	Recovery  float64
+	// average latency of the first command committed by the new leader
+	Commit float64
+}
+
+// Time to first commit after the crash.
+func (f *Failover) Total() float64 {
+	return Round(f.Detection + f.Election + f.Recovery + f.Commit)
+}
+
+// Round trip from `leader` to the closest alive majority of `rs`.
+func Phase1(t *LatencyTable, rs []string, leader string) float64 {
+	if leader == "" || IsCrashed(leader) {
+		return math.Inf(1)
+	}
+	_, m := ClosestQuorum(t, rs, leader, len(rs)/2+1)
+	return m
+}
+
+// Round trip from `leader` to the closest alive recovery quorum of `rs`:
+// n-f replicas, f being the number of tolerated failures, whose fast-path
+// votes reveal the commands that might have been committed on the fast
+// path, and which accept their re-proposal.
+func Recovery(t *LatencyTable, rs []string, leader string) float64 {
+	if leader == "" || IsCrashed(leader) {
+		return math.Inf(1)
+	}
+	f := (len(rs) - 1) / 2
+	_, m := ClosestQuorum(t, rs, leader, len(rs)-f)
+	return m
+}
+
+// Estimates failovers of every leader-based protocol over replicas `rs`
+// with clients `cs`. The new leader is the optimal one among survivors.
+func Failovers(t *LatencyTable, rs, cs []string) []*Failover {
+	var fs []*Failover
+	for _, p := range Protocols(t, rs, cs) {
+		if p.Leader == "<leaderless>" {
+			continue
+		}
+		f := &Failover{
+			Name:      p.Name,
+			Old:       p.Leader,
+			Detection: FailureTimeout,
+			Election:  math.Inf(1),
+			Commit:    math.Inf(1),
+		}
+		if p.Leader != "" {
+			crashed := Crashed.Copy()
+			crashed[p.Leader] = struct{}{}
+			WithCrashed(crashed, func() {
+				for _, np := range Protocols(t, rs, cs) {
+					if np.Name != p.Name {
+						continue
+					}
+					f.New = np.Leader
+					f.Election = Phase1(t, rs, np.Leader)
+					if p.TwoPaths {
+						f.Recovery = Recovery(t, rs, np.Leader)
+					}
+					f.Commit = Average(np.Alg, cs, true)
+				}
+			})
+		}
+		if p.TwoPaths && p.Leader == "" {
+			f.Recovery = math.Inf(1)
+		}
+		fs = append(fs, f)
+	}
+	return fs
+}
+
+func FailoverReport(t *LatencyTable, rs, cs []string) string {
+	s := fmt.Sprintf("failure-detection timeout: %vms\n", FormatMs(FailureTimeout))
+	id := func(r string) string {
+		if r == "" {
+			return "none"
+		}
+		return t.IdOf(r)
+	}
+	for _, f := range Failovers(t, rs, cs) {
+		s += fmt.Sprintf("\n%v: leader %v -> %v\n", f.Name, id(f.Old), id(f.New))
+		s += "  detection    " + FormatLatency(f.Detection) + "\n"
+		s += "  election     " + FormatLatency(f.Election) + "\n"
+		if f.Recovery != 0 {
+			s += "  recovery     " + FormatLatency(f.Recovery) + "\n"
+		}
+		s += "  first commit " + FormatLatency(f.Commit) + "\n"
+		s += "  total        " + FormatLatency(f.Total()) + "\n"
+	}
+	return s
+}
@@ -0,0 +1,32 @@
+package main
+
+import "math"
+
+// Runs `f` with replicas `crashed` considered crashed.
+func WithCrashed(crashed Quorum, f func()) {
+	old := Crashed
+	Crashed = crashed
+	defer func() {
+		Crashed = old
+	}()
+	f()
+}
+
+// Returns the replica of `rs` whose crash degrades the average latency
+// of protocol `name` for clients `cs` the most, and that latency.
+func WorstSingleFailure(t *LatencyTable, rs, cs []string, name string) (string, float64) {
+	worst, max := "", math.Inf(-1)
+	for _, r := range rs {
+		WithCrashed(QuorumOfSlice([]string{r}), func() {
+			for _, p := range Protocols(t, rs, cs) {
+				if p.Name != name {
+					continue
+				}
+				if l := Average(p.Alg, cs, true); l > max {
+					worst, max = r, l
+				}
+			}
+		})
+	}
+	return worst, max
+}
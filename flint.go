@@ -1,34 +1,434 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 )
 
-var latencyTableFile = flag.String("l", "", "latency config file")
+var (
+	latencyTableFile = flag.String("l", "", "latency source (file:, csv:, tsv:, json:, builtin: or cloudping: followed by path, name or percentile/timeframe)")
+	percentile       = flag.String("percentile", "", "cloudping percentile (p10, p25, p50, p75, p90, p98 or p99) of cloudping sources that do not set one")
+	timeframe        = flag.String("timeframe", "", "cloudping timeframe (1D, 1W, 1M or 1Y) of cloudping sources that do not set one")
+	refresh          = flag.Bool("refresh", false, "never fall back to cached cloudping data")
+	offline          = flag.Bool("offline", false, "use cached cloudping data only")
+	precision        = flag.Int("precision", Precision, "number of decimal digits of ms kept in computations (-1 to disable rounding)")
+	check            = flag.Bool("check", false, "validate the latency table and exit")
+	repair           = flag.String("repair", "none", "what to do with latency table issues (none, symmetric, shortest, coordinates or abort)")
+	asymmetry        = flag.Float64("asymmetry", AsymmetryThreshold, "relative asymmetry of a link reported by validation")
+	groupsFile       = flag.String("groups", "", "region groups file")
+	coordsFile       = flag.String("coords", "", "region coordinates file")
+	estimate         = flag.Bool("estimate", false, "estimate missing links from region coordinates")
+	fiberSpeed       = flag.Float64("fiber-speed", FiberSpeed, "propagation speed in fiber (km/ms) used by -estimate")
+	inflation        = flag.Float64("inflation", RouteInflation, "ratio between route length and great-circle distance used by -estimate")
+	mergeSources     = flag.String("merge", "", "comma-separated latency sources merged into the one of -l")
+	mergePolicy      = flag.String("merge-policy", "first", "how merged links are resolved (first, last, min, max or mean), or one policy per -merge source")
+	diffSource       = flag.String("diff", "", "compare the latency table with this latency source and exit")
+	diffThreshold    = flag.Float64("diff-threshold", 0, "smallest change (ms) of a link reported by -diff")
+	replicasList     = flag.String("replicas", "", "comma-separated replicas used by -diff, -failover, -availability, -cost, -throughput, -charts, -table and -partition")
+	clientsList      = flag.String("clients", "", "comma-separated clients used by -diff, -failover, -availability, -cost, -throughput, -charts, -table and -partition (replicas by default)")
+	aliasesFile      = flag.String("aliases", "", "region aliases file")
+	sitesFile        = flag.String("sites", "", "client sites file")
+	weights          = flag.String("weights", "", "client weights as region=weight,... or a file with lines of region and weight")
+	crash            = flag.String("crash", "", "comma-separated replicas considered crashed")
+	partition        = flag.String("partition", "", "network partitions separated by ;, each made of sides separated by | of comma-separated regions")
+	failureProb      = flag.String("failure-probability", "", "probability that a region is unavailable, as a default probability, region=probability,... or a file with lines of region and probability")
+	availability     = flag.Bool("availability", false, "print availability of quorum systems over -replicas for -clients and exit")
+	messageSizes     = flag.String("message-sizes", "", "message sizes in bytes as command=bytes,ack=bytes,deps=bytes")
+	egressPrices     = flag.String("egress-prices", "", "egress price ($/GB) as a default price or a file with lines of src [dst] price")
+	cost             = flag.Bool("cost", false, "print messages, bytes and egress cost per command of every protocol over -replicas for -clients and exit")
+	processingDelay  = flag.Duration("processing-delay", 0, "delay of replicas handling a message")
+	fsyncDelay       = flag.Duration("fsync-delay", 0, "delay of replicas persisting a message")
+	delaysFile       = flag.String("delays", "", "replica delays file with lines of region, processing delay and fsync delay")
+	batchTimeout     = flag.Duration("batch-timeout", 0, "time after which leaders close a batch")
+	batchSize        = flag.Int("batch-size", BatchSize, "number of commands after which leaders close a batch (1 disables batching)")
+	arrivalRate      = flag.Float64("arrival-rate", ArrivalRate, "arrival rate of commands at leaders (commands per second) used by batching")
+	messageCost      = flag.Float64("message-cost", DefaultMessageCost, "CPU time (µs) a replica spends sending or receiving a message")
+	bandwidth        = flag.Float64("bandwidth", DefaultBandwidth, "NIC bandwidth (Gbps) of replicas")
+	capacitiesFile   = flag.String("capacities", "", "replica capacities file with lines of region, message cost (µs) and bandwidth (Gbps)")
+	throughput       = flag.Bool("throughput", false, "print maximum throughput and latency under load of every protocol over -replicas for -clients and exit")
+	failover         = flag.Bool("failover", false, "print leader failover estimates for -replicas and -clients and exit")
+	charts           = flag.String("charts", "", "write bar, CDF and speedup charts (SVG) of fast and slow paths of every protocol over -replicas for -clients into this directory and exit")
+	chartBaseline    = flag.String("chart-baseline", "Paxos", "protocol to which speedup charts compare other protocols")
+	table            = flag.String("table", "", "write the comparison of -table-protocol with other protocols over -replicas for -clients as a LaTeX (.tex) or Markdown table and exit")
+	tableProtocol    = flag.String("table-protocol", "SwiftPaxos", "protocol compared with the other ones by -table")
+	tableRows        = flag.String("table-rows", "both", "latencies compared by -table: fast, slow or both")
+	tableSites       = flag.Bool("table-sites", true, "name clients by their sites in -table")
+	search           = flag.String("search", "", "search placements of n replicas and m clients given as n,m among -replicas (all regions by default) where the first -search-protocols protocol is at least 10% faster than the second, and exit")
+	searchProtocols  = flag.String("search-protocols", "SwiftPaxos,Paxos", "two protocols compared by -search")
+	searchGroup      = flag.String("search-group", "north-america", "region group hosting at least one replica in -search (empty for any)")
+	searchLimit      = flag.Int("search-limit", 10, "number of placements printed by -search")
+	failureTimeout   = flag.Float64("failure-timeout", FailureTimeout, "failure-detection timeout (ms) used by leader failover estimates")
+	exportFile       = flag.String("export", "", "export the latency table (.csv, .tsv, .json or latency config file) and exit")
+)
 
 func main() {
 	var (
-		t   *LatencyTable
+		src LatencySource
 		err error
 	)
 
 	flag.Parse()
+	Precision = *precision
+	FiberSpeed = *fiberSpeed
+	RouteInflation = *inflation
+	FailureTimeout = *failureTimeout
+	DefaultMessageCost = *messageCost
+	BatchTimeout = float64(*batchTimeout) / float64(time.Millisecond)
+	BatchSize = *batchSize
+	ArrivalRate = *arrivalRate
+	DefaultProcessingDelay = float64(*processingDelay) / float64(time.Millisecond)
+	DefaultFsyncDelay = float64(*fsyncDelay) / float64(time.Millisecond)
+	DefaultBandwidth = *bandwidth
+	if *messageSizes != "" {
+		if err := ParseMessageSizes(*messageSizes); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
+	if *refresh && *offline {
+		fmt.Println("-refresh and -offline are mutually exclusive")
+		return
+	}
+	mode := CacheDefault
+	if *refresh {
+		mode = CacheRefresh
+	} else if *offline {
+		mode = CacheOffline
+	}
 
 	if *latencyTableFile != "" {
-		t, err = NewLatencyTableFromFile(*latencyTableFile)
+		src, err = ParseLatencySource(*latencyTableFile)
 		if err != nil {
 			fmt.Println(err)
 			return
 		}
 	} else {
-		t, err = NewLatencyTable()
+		src = &CloudpingSource{}
+	}
+	t, err := load(src, mode)
+	if err != nil {
+		fmt.Println(err)
+		if _, ok := src.(*CloudpingSource); ok {
+			fmt.Println("Try calling flint with latency config file via -l option")
+		}
+		return
+	}
+	if err := Prepare(t); err != nil {
+		fmt.Println(err)
+		return
+	}
+	if *mergeSources != "" {
+		uris := strings.Split(*mergeSources, ",")
+		policies, err := ParseMergePolicies(*mergePolicy, len(uris))
 		if err != nil {
 			fmt.Println(err)
-			fmt.Println("Try calling flint with latency config file via -l option")
 			return
 		}
+		// the main table has no earlier links to conflict with
+		policies = append([]MergePolicy{MergeFirst}, policies...)
+		ts := []*LatencyTable{t}
+		for _, uri := range uris {
+			mt, err := loadURI(uri, mode)
+			if err == nil {
+				err = Prepare(mt)
+			}
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			ts = append(ts, mt)
+		}
+		t = Merge(ts, policies)
+	}
+	if err := Setup(t); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	AsymmetryThreshold = *asymmetry
+	if *repair == "abort" {
+		if es := Errors(t.Validate()); len(es) != 0 {
+			fmt.Print(t.Report(es))
+			os.Exit(1)
+		}
+	} else if mode, ok := ParseRepairMode(*repair); ok {
+		if n := t.Repair(mode); n > 0 && *check {
+			fmt.Printf("%d link(s) filled\n", n)
+		}
+	} else {
+		fmt.Println("unknown repair mode", *repair)
+		return
+	}
+	if *check {
+		is := t.Validate()
+		fmt.Print(t.Report(is))
+		if len(Errors(is)) != 0 {
+			os.Exit(1)
+		}
+		if len(is) == 0 {
+			fmt.Println("no issues found")
+		}
+		return
+	}
+
+	if *diffSource != "" {
+		t2, err := loadURI(*diffSource, mode)
+		if err == nil {
+			err = Prepare(t2)
+		}
+		if err == nil {
+			err = SetupTable(t2)
+		}
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		rs := strings.Split(*replicasList, ",")
+		cs := rs
+		if *clientsList != "" {
+			cs = strings.Split(*clientsList, ",")
+		}
+		if *replicasList == "" {
+			rs, cs = nil, nil
+		}
+		d, err := Diff(t, t2, rs, cs, *diffThreshold)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Print(d)
+		return
+	}
+
+	if *search != "" {
+		if err := Search(t); err != nil {
+			fmt.Println(err)
+		}
+		return
+	}
+
+	if *failover || *availability || *cost || *throughput || *charts != "" || *table != "" || (*partition != "" && *replicasList != "") {
+		rs, unknown := t.ParseRegions(*replicasList)
+		cs, unknownCs := t.ParseRegions(*clientsList)
+		if *clientsList == "" {
+			cs = rs
+		}
+		if unknown = append(unknown, unknownCs...); len(unknown) != 0 {
+			fmt.Println("unknown regions", strings.Join(unknown, ", "))
+			return
+		}
+		if len(rs) == 0 {
+			fmt.Println("-failover, -availability, -cost, -throughput, -charts and -table require -replicas")
+			return
+		}
+		if *partition != "" {
+			ps, _ := t.ParsePartitions(*partition)
+			for i, p := range ps {
+				if i != 0 {
+					fmt.Println()
+				}
+				WithPartition(p, func() {
+					fmt.Print(PartitionReport(t, rs, cs))
+				})
+			}
+			return
+		}
+		if *availability {
+			fmt.Print(AvailabilityReport(t, rs, cs))
+		}
+		if *cost {
+			fmt.Print(CostReport(t, rs, cs))
+		}
+		if *throughput {
+			fmt.Print(ThroughputReport(t, rs, cs))
+		}
+		if *failover {
+			fmt.Print(FailoverReport(t, rs, cs))
+		}
+		if *charts != "" {
+			if err := ExportCharts(t, rs, cs, *chartBaseline, *charts); err != nil {
+				fmt.Println(err)
+			}
+		}
+		if *table != "" {
+			if err := ExportComparison(t, rs, cs, *tableProtocol, *tableRows, *tableSites, *table); err != nil {
+				fmt.Println(err)
+			}
+		}
+		return
+	}
+
+	if *exportFile != "" {
+		if err := t.Export(t.regions, *exportFile); err != nil {
+			fmt.Println(err)
+		}
+		return
 	}
 
 	RunUI(t)
 }
+
+// Loads `src`. Cloudping sources take -percentile and -timeframe unless
+// they set their own.
+func load(src LatencySource, mode CacheMode) (*LatencyTable, error) {
+	if c, ok := src.(*CloudpingSource); ok {
+		c.Mode = mode
+		if err := c.SetDefaults(*percentile, *timeframe); err != nil {
+			return nil, err
+		}
+	}
+	return src.Load()
+}
+
+func loadURI(uri string, mode CacheMode) (*LatencyTable, error) {
+	src, err := ParseLatencySource(uri)
+	if err != nil {
+		return nil, err
+	}
+	return load(src, mode)
+}
+
+// Loads the sites, coordinates and groups of a latency table and estimates
+// its missing links.
+func SetupTable(t *LatencyTable) error {
+	if *sitesFile != "" {
+		if err := t.LoadSites(*sitesFile); err != nil {
+			return err
+		}
+	}
+	if *coordsFile != "" {
+		if err := t.LoadCoords(*coordsFile); err != nil {
+			return err
+		}
+	}
+	if *estimate {
+		t.Estimate()
+	}
+	if *groupsFile != "" {
+		if err := t.LoadGroups(*groupsFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Renames regions of a freshly loaded latency table after -aliases.
+func Prepare(t *LatencyTable) error {
+	var aliases *Aliases
+	if *aliasesFile != "" {
+		a, err := LoadAliases(*aliasesFile)
+		if err != nil {
+			return err
+		}
+		aliases = a
+	}
+	t.Canonicalize(aliases)
+	return nil
+}
+
+// Applies command-line configuration to the latency table in use, once
+// every source is prepared and merged.
+func Setup(t *LatencyTable) error {
+	if err := SetupTable(t); err != nil {
+		return err
+	}
+	if *crash != "" {
+		rs, unknown := t.ParseRegions(*crash)
+		if len(unknown) != 0 {
+			return errors.New("unknown crashed replicas " + strings.Join(unknown, ", "))
+		}
+		for _, r := range rs {
+			Crashed[r] = struct{}{}
+		}
+	}
+	if *partition != "" {
+		ps, err := t.ParsePartitions(*partition)
+		if err != nil {
+			return err
+		}
+		if len(ps) != 0 {
+			Partitioned = ps[0]
+		}
+	}
+	if *egressPrices != "" {
+		if err := t.LoadEgressPrices(*egressPrices); err != nil {
+			return err
+		}
+	}
+	if *delaysFile != "" {
+		if err := t.LoadDelays(*delaysFile); err != nil {
+			return err
+		}
+	}
+	if *capacitiesFile != "" {
+		if err := t.LoadCapacities(*capacitiesFile); err != nil {
+			return err
+		}
+	}
+	if *failureProb != "" {
+		ps, p, err := t.ParseFailureProbabilities(*failureProb)
+		if err != nil {
+			return err
+		}
+		DefaultFailureProbability = p
+		for r, p := range ps {
+			FailureProbabilities[r] = p
+		}
+	}
+	if *weights != "" {
+		ws, err := t.ParseWeights(*weights)
+		if err != nil {
+			return err
+		}
+		for r, w := range ws {
+			ClientWeights[r] = w
+		}
+	}
+	return nil
+}
+
+// Prints the placements found by -search.
+func Search(t *LatencyTable) error {
+	var n, m int
+	if _, err := fmt.Sscanf(*search, "%d,%d", &n, &m); err != nil || n < 1 || m < 2 {
+		return errors.New("-search expects n,m with at least 1 replica and 2 clients")
+	}
+	ms := t.regions
+	if *replicasList != "" {
+		var unknown []string
+		if ms, unknown = t.ParseRegions(*replicasList); len(unknown) != 0 {
+			return errors.New("unknown regions " + strings.Join(unknown, ", "))
+		}
+	}
+	if *searchGroup != "" && t.Group(*searchGroup) == nil {
+		return errors.New("unknown region group " + *searchGroup)
+	}
+	names := strings.Split(*searchProtocols, ",")
+	if len(names) != 2 {
+		return errors.New("-search-protocols expects two protocols")
+	}
+	var algs []Algorithm
+	for _, name := range names {
+		for _, p := range Protocols(t, nil, nil) {
+			if p.Name == strings.TrimSpace(name) {
+				algs = append(algs, p.Alg)
+			}
+		}
+	}
+	if len(algs) != 2 {
+		return errors.New("unknown protocol in " + *searchProtocols)
+	}
+	reconf := func(alg Algorithm) func(rs, cs []string) {
+		return func(rs, cs []string) {
+			Reconfigure(alg, rs, cs)
+		}
+	}
+	configs := Configs(ms, n, m, *searchGroup, algs[0], algs[1], true, true, reconf(algs[0]), reconf(algs[1]), t)
+	fmt.Print(ConfigsReport(t, configs, *searchLimit))
+	return nil
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Hop is a message of a command's flow, sent at Send and received at
+// Arrive (ms since the client sent the command). Critical hops are sent
+// by the quorum member that arrives last.
+type Hop struct {
+	From, To     string
+	Label        string
+	Send, Arrive float64
+	Critical     bool
+}
+
+func hop(t *LatencyTable, from, to, label string, send float64) *Hop {
+	return &Hop{
+		From:   from,
+		To:     to,
+		Label:  label,
+		Send:   Round(send),
+		Arrive: Round(send + t.OneWayLatency(from, to)),
+	}
+}
+
+// Marks the hop of `hs` completing a quorum of `size` as critical and
+// returns it, nil if there are not enough hops.
+func quorumLast(hs []*Hop, size int) *Hop {
+	if size <= 0 || len(hs) < size {
+		return nil
+	}
+	sorted := append([]*Hop{}, hs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Arrive < sorted[j].Arrive
+	})
+	sorted[size-1].Critical = true
+	return sorted[size-1]
+}
+
+// Time at which `client` learns the outcome of flow `hs`, i.e., receives
+// a critical hop or a message sent after its sender received one.
+func flowEnd(hs []*Hop, client string) float64 {
+	end := math.Inf(-1)
+	for _, h := range hs {
+		if h.To != client {
+			continue
+		}
+		informed := h.Critical
+		for _, k := range hs {
+			informed = informed || k.Critical && k.To == h.From && k.Arrive <= h.Send
+		}
+		if informed {
+			end = math.Max(end, h.Arrive)
+		}
+	}
+	if math.IsInf(end, -1) {
+		return math.Inf(1)
+	}
+	return end
+}
+
+// Participants of flow `hs` in order of appearance.
+func participants(hs []*Hop) []string {
+	var ps []string
+	for _, h := range hs {
+		for _, r := range []string{h.From, h.To} {
+			if !contains(ps, r) {
+				ps = append(ps, r)
+			}
+		}
+	}
+	return ps
+}
+
+func sortedHops(hs []*Hop) []*Hop {
+	sorted := append([]*Hop{}, hs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Send == sorted[j].Send {
+			return sorted[i].Arrive < sorted[j].Arrive
+		}
+		return sorted[i].Send < sorted[j].Send
+	})
+	return sorted
+}
+
+// Header of a flow diagram of `client` with `alg`, mentioning the latency
+// of the model if the drawn flow does not account for all of it.
+func flowTitle(t *LatencyTable, alg Algorithm, client string, fast bool, hs []*Hop) string {
+	s := fmt.Sprintf("%v with %v", t.Site(client), alg)
+	if l := alg.Accept(client, fast); math.Abs(l-flowEnd(hs, client)) > 0.001 && !math.IsInf(l, 1) {
+		s += fmt.Sprintf(" (model latency %0.3f)", l)
+	}
+	return s
+}
+
+// Draws flow `hs` of `client` with `alg` as an ASCII sequence diagram,
+// with critical hops in red if `colors` is set.
+func FlowDiagram(t *LatencyTable, alg Algorithm, client string, fast bool, hs []*Hop, colors bool) string {
+	if len(hs) == 0 {
+		return t.Site(client) + " cannot commit with " + alg.String() + "\n"
+	}
+	ps := participants(hs)
+	width := 12
+	for _, p := range ps {
+		if n := utf8.RuneCountInString(t.Site(p)) + 2; n > width {
+			width = n
+		}
+	}
+	if width > 20 {
+		width = 20
+	}
+	center := func(p string) int {
+		for i, q := range ps {
+			if q == p {
+				return i*width + width/2
+			}
+		}
+		return 0
+	}
+
+	s := flowTitle(t, alg, client, fast, hs) + "\n\n"
+	header := []rune(strings.Repeat(" ", len(ps)*width))
+	for i, p := range ps {
+		name := []rune(t.Site(p))
+		if len(name) > width-1 {
+			name = name[:width-1]
+		}
+		copy(header[i*width+(width-len(name))/2:], name)
+	}
+	s += strings.Repeat(" ", 9) + strings.TrimRight(string(header), " ") + "\n"
+
+	for _, h := range sortedHops(hs) {
+		line := []rune(strings.Repeat(" ", len(ps)*width))
+		for _, p := range ps {
+			line[center(p)] = '|'
+		}
+		x1, x2 := center(h.From), center(h.To)
+		label := []rune(h.Label)
+		if x1 == x2 {
+			line[x1] = '*'
+			copy(line[x1+2:], label)
+		} else {
+			lo, hi := x1, x2
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			for x := lo + 1; x < hi; x++ {
+				line[x] = '-'
+			}
+			if x2 > x1 {
+				line[x2-1] = '>'
+			} else {
+				line[x2+1] = '<'
+			}
+			if len(label)+2 < hi-lo-2 {
+				copy(line[lo+(hi-lo-len(label))/2:], label)
+			}
+		}
+		row := fmt.Sprintf("%8.3f %v  %8.3f", h.Send, string(line), h.Arrive)
+		if h.Critical {
+			row += " last"
+			if colors {
+				row = "[red]" + row + "[white]"
+			}
+		}
+		s += row + "\n"
+	}
+	return s
+}
+
+// Describes flow `hs` as a Mermaid sequence diagram.
+func FlowMermaid(t *LatencyTable, alg Algorithm, client string, fast bool, hs []*Hop) string {
+	ps := participants(hs)
+	id := func(r string) string {
+		for i, p := range ps {
+			if p == r {
+				return fmt.Sprintf("p%d", i)
+			}
+		}
+		return ""
+	}
+	s := "sequenceDiagram\n"
+	s += "  title " + flowTitle(t, alg, client, fast, hs) + "\n"
+	for _, p := range ps {
+		s += fmt.Sprintf("  participant %v as %v\n", id(p), t.Site(p))
+	}
+	for _, h := range sortedHops(hs) {
+		label := fmt.Sprintf("%v (%0.3f → %0.3f)", h.Label, h.Send, h.Arrive)
+		if h.From == h.To {
+			s += fmt.Sprintf("  Note over %v: %v\n", id(h.From), label)
+		} else {
+			s += fmt.Sprintf("  %v->>%v: %v\n", id(h.From), id(h.To), label)
+		}
+		if h.Critical {
+			s += fmt.Sprintf("  Note over %v: last quorum member\n", id(h.From))
+		}
+	}
+	return s
+}
+
+// Draws flow `hs` as an SVG sequence diagram whose vertical axis is time.
+func FlowSVG(t *LatencyTable, alg Algorithm, client string, fast bool, hs []*Hop) string {
+	ps := participants(hs)
+	const (
+		colWidth = 160.0
+		top      = 60.0
+		scale    = 2.0 // px per ms
+	)
+	end := 0.0
+	for _, h := range hs {
+		end = math.Max(end, h.Arrive)
+	}
+	w, h := colWidth*float64(len(ps))+80, top+end*scale+40
+	x := func(r string) float64 {
+		for i, p := range ps {
+			if p == r {
+				return 80 + colWidth*float64(i) + colWidth/2
+			}
+		}
+		return 0
+	}
+	y := func(ms float64) float64 {
+		return top + ms*scale
+	}
+
+	s := svgHeader(w, h)
+	s += `<defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="6" markerHeight="6" orient="auto-start-reverse"><path d="M 0 0 L 10 5 L 0 10 z"/></marker>` +
+		`<marker id="arrow-last" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="6" markerHeight="6" orient="auto-start-reverse"><path d="M 0 0 L 10 5 L 0 10 z" fill="red"/></marker></defs>` + "\n"
+	s += fmt.Sprintf(`<text x="10" y="20" font-weight="bold">%v</text>`+"\n", html.EscapeString(flowTitle(t, alg, client, fast, hs)))
+	for _, p := range ps {
+		s += fmt.Sprintf(`<text x="%.1f" y="%.1f" text-anchor="middle">%v</text>`+"\n", x(p), top-10, html.EscapeString(t.Site(p)))
+		s += fmt.Sprintf(`<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="grey" stroke-dasharray="4"/>`+"\n", x(p), top, x(p), h-20)
+	}
+	for _, hp := range sortedHops(hs) {
+		color, marker := "black", "arrow"
+		if hp.Critical {
+			color, marker = "red", "arrow-last"
+		}
+		s += fmt.Sprintf(`<text x="5" y="%.1f" fill="grey">%0.1f</text>`+"\n", y(hp.Send)+4, hp.Send)
+		if hp.From == hp.To {
+			s += fmt.Sprintf(`<circle cx="%.1f" cy="%.1f" r="3" fill="%v"/>`+"\n", x(hp.From), y(hp.Send), color)
+			continue
+		}
+		s += fmt.Sprintf(`<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%v" marker-end="url(#%v)"/>`+"\n",
+			x(hp.From), y(hp.Send), x(hp.To), y(hp.Arrive), color, marker)
+		s += fmt.Sprintf(`<text x="%.1f" y="%.1f" text-anchor="middle" fill="%v">%v</text>`+"\n",
+			(x(hp.From)+x(hp.To))/2, (y(hp.Send)+y(hp.Arrive))/2-4, color, html.EscapeString(hp.Label))
+	}
+	s += "</svg>\n"
+	return s
+}
+
+// Exports flow `hs` into `filename`: ".svg" files are drawn, ".mmd" and
+// ".md" files contain a Mermaid diagram and anything else an ASCII one.
+func ExportFlow(t *LatencyTable, alg Algorithm, client string, fast bool, hs []*Hop, filename string) error {
+	var s string
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".svg":
+		s = FlowSVG(t, alg, client, fast, hs)
+	case ".mmd":
+		s = FlowMermaid(t, alg, client, fast, hs)
+	case ".md":
+		s = "```mermaid\n" + FlowMermaid(t, alg, client, fast, hs) + "```\n"
+	default:
+		s = FlowDiagram(t, alg, client, fast, hs, false)
+	}
+	return ioutil.WriteFile(filename, []byte(s), 0644)
+}
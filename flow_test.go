@@ -0,0 +1,20 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFlowMatchesAccept(t *testing.T) {
+	lt, rs, cs := lineTable(t)
+	for _, p := range Protocols(lt, rs, cs) {
+		for _, c := range cs {
+			for _, fast := range []bool{true, false} {
+				l := p.Alg.Accept(c, fast)
+				if end := flowEnd(p.Alg.Flow(c, fast), c); math.Abs(end-l) > 0.001 {
+					t.Errorf("%v, fast %v: flow of %v ends at %v, latency %v", p.Name, fast, c, end, l)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type Coord struct {
+	Lat  float64
+	Long float64
+}
+
+type link struct {
+	r1, r2 string
+}
+
+var (
+	// propagation speed in fiber (km/ms), about 2/3 of the speed of light
+	FiberSpeed = 200.0
+	// ratio between the length of network routes and great-circle distance
+	RouteInflation = 1.5
+
+	earthRadius = 6371.0
+
+	// approximate locations of cloud regions
+	regionCoords = map[string]Coord{
+		// AWS
+		"us-east-1":      {38.13, -78.45},
+		"us-east-2":      {39.96, -83.00},
+		"us-west-1":      {37.35, -121.96},
+		"us-west-2":      {45.92, -119.27},
+		"ca-central-1":   {45.50, -73.57},
+		"sa-east-1":      {-23.55, -46.63},
+		"eu-west-1":      {53.35, -6.26},
+		"eu-west-2":      {51.51, -0.13},
+		"eu-west-3":      {48.86, 2.35},
+		"eu-central-1":   {50.11, 8.68},
+		"eu-north-1":     {59.33, 18.07},
+		"eu-south-1":     {45.46, 9.19},
+		"ap-south-1":     {19.08, 72.88},
+		"ap-east-1":      {22.32, 114.17},
+		"ap-northeast-1": {35.41, 139.42},
+		"ap-northeast-2": {37.57, 126.98},
+		"ap-northeast-3": {34.69, 135.50},
+		"ap-southeast-1": {1.35, 103.82},
+		"ap-southeast-2": {-33.87, 151.21},
+		"me-south-1":     {26.07, 50.56},
+		"af-south-1":     {-33.92, 18.42},
+		// GCP
+		"us-central1":             {41.26, -95.86},
+		"us-east1":                {33.20, -80.01},
+		"us-east4":                {39.03, -77.49},
+		"us-west1":                {45.60, -121.18},
+		"northamerica-northeast1": {45.50, -73.57},
+		"southamerica-east1":      {-23.55, -46.63},
+		"europe-west1":            {50.45, 3.82},
+		"europe-west2":            {51.51, -0.13},
+		"europe-west3":            {50.11, 8.68},
+		"asia-east1":              {24.05, 120.52},
+		"asia-northeast1":         {35.69, 139.69},
+		"asia-south1":             {19.08, 72.88},
+		"asia-southeast1":         {1.35, 103.82},
+		"australia-southeast1":    {-33.87, 151.21},
+		"me-west1":                {32.09, 34.78},
+		"africa-south1":           {-26.20, 28.05},
+		// Azure
+		"eastus":             {37.37, -79.82},
+		"eastus2":            {36.67, -78.39},
+		"centralus":          {41.59, -93.60},
+		"westus2":            {47.23, -119.85},
+		"canadacentral":      {43.65, -79.38},
+		"brazilsouth":        {-23.55, -46.63},
+		"northeurope":        {53.35, -6.26},
+		"westeurope":         {52.37, 4.90},
+		"uksouth":            {50.94, -0.80},
+		"francecentral":      {46.36, 2.37},
+		"germanywestcentral": {50.11, 8.68},
+		"japaneast":          {35.68, 139.77},
+		"southeastasia":      {1.28, 103.83},
+		"centralindia":       {18.58, 73.92},
+		"australiaeast":      {-33.86, 151.21},
+		"southafricanorth":   {-25.73, 28.22},
+		"uaenorth":           {25.27, 55.30},
+	}
+)
+
+func (c Coord) String() string {
+	return fmt.Sprintf("%v %v", c.Lat, c.Long)
+}
+
+// Great-circle distance in km.
+func Distance(c1, c2 Coord) float64 {
+	lat1, lat2 := c1.Lat*math.Pi/180, c2.Lat*math.Pi/180
+	dLat, dLong := lat2-lat1, (c2.Long-c1.Long)*math.Pi/180
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLong/2), 2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+// Returns the coordinates of `r`, either set explicitly or known for the
+// cloud region `r`.
+func (t *LatencyTable) Coord(r string) (Coord, bool) {
+	if c, exists := t.coords[r]; exists {
+		return c, true
+	}
+	id := NormalizeRegion(t.IdOf(r))
+	if _, rest, found := strings.Cut(id, "/"); found {
+		id = rest
+	}
+	c, exists := regionCoords[id]
+	return c, exists
+}
+
+// Sets the coordinates of `name`, adding it as a new region if needed.
+func (t *LatencyTable) SetCoord(name string, c Coord) {
+	r, exists := t.Region(name)
+	if !exists {
+		r = name
+		t.addRegion(r)
+	}
+	t.coords[r] = c
+}
+
+func parseCoord(lat, long string) (Coord, error) {
+	la, err := strconv.ParseFloat(lat, 64)
+	if err != nil {
+		return Coord{}, err
+	}
+	lo, err := strconv.ParseFloat(long, 64)
+	if err != nil {
+		return Coord{}, err
+	}
+	if math.Abs(la) > 90 || math.Abs(lo) > 180 {
+		return Coord{}, errors.New("coordinates out of range: " + lat + " " + long)
+	}
+	return Coord{la, lo}, nil
+}
+
+// Loads coordinates from a file with lines of the form
+//
+//	region latitude longitude
+//
+// Unknown regions are added to the table.
+func (t *LatencyTable) LoadCoords(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		data := strings.Fields(s.Text())
+		if len(data) > 0 && data[0] == "coord" {
+			data = data[1:]
+		}
+		if len(data) != 3 || strings.HasPrefix(data[0], "#") {
+			continue
+		}
+		c, err := parseCoord(data[1], data[2])
+		if err != nil {
+			return errors.New(filename + ": " + err.Error())
+		}
+		t.SetCoord(data[0], c)
+	}
+	return s.Err()
+}
+
+// Estimated round-trip latency (ms) between two locations.
+func EstimateLatency(c1, c2 Coord) float64 {
+	return 2 * Distance(c1, c2) / FiberSpeed * RouteInflation
+}
+
+// Fills missing links between regions with known coordinates and returns
+// the number of added links.
+func (t *LatencyTable) Estimate() int {
+	n := 0
+	for i, r1 := range t.regions {
+		c1, ok := t.Coord(r1)
+		if !ok {
+			continue
+		}
+		for _, r2 := range t.regions[i+1:] {
+			if t.HasLink(r1, r2) || (t.IsSite(r1) && t.IsSite(r2)) {
+				continue
+			}
+			c2, ok := t.Coord(r2)
+			if !ok {
+				continue
+			}
+			l := EstimateLatency(c1, c2)
+			t.latency[r1][r2] = l
+			t.latency[r2][r1] = l
+			t.estimated[link{r1, r2}] = struct{}{}
+			t.estimated[link{r2, r1}] = struct{}{}
+			n += 2
+		}
+	}
+	return n
+}
+
+func (t *LatencyTable) IsEstimated(r1, r2 string) bool {
+	_, exists := t.estimated[link{r1, r2}]
+	return exists
+}
+
+// Reports whether any link of `r` is estimated.
+func (t *LatencyTable) HasEstimates(r string) bool {
+	for l := range t.estimated {
+		if l.r1 == r || l.r2 == r {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"strings"
+)
+
+type RegionGroup struct {
+	Name    string
+	Regions []string
+}
+
+// Returns the continent of an AWS, GCP or Azure region, or "other".
+func Continent(r string) string {
+	f := strings.Fields(strings.ToLower(r))
+	if len(f) == 0 {
+		return "other"
+	}
+	id := f[len(f)-1]
+	if p, r, found := strings.Cut(id, "/"); found && contains(providers, p) {
+		id = r
+	}
+
+	prefixes := []struct {
+		prefix    string
+		continent string
+	}{
+		// AWS and GCP
+		{"us-", "north-america"},
+		{"ca-", "north-america"},
+		{"mx-", "north-america"},
+		{"northamerica-", "north-america"},
+		{"sa-", "south-america"},
+		{"southamerica-", "south-america"},
+		{"eu-", "europe"},
+		{"europe-", "europe"},
+		{"ap-", "asia"},
+		{"cn-", "asia"},
+		{"asia-", "asia"},
+		{"australia-", "oceania"},
+		{"me-", "middle-east"},
+		{"il-", "middle-east"},
+		{"af-", "africa"},
+		{"africa-", "africa"},
+	}
+	for _, p := range prefixes {
+		if strings.HasPrefix(id, p.prefix) {
+			return p.continent
+		}
+	}
+
+	// Azure
+	keywords := []struct {
+		keyword   string
+		continent string
+	}{
+		{"australia", "oceania"},
+		{"newzealand", "oceania"},
+		{"southafrica", "africa"},
+		{"uae", "middle-east"},
+		{"qatar", "middle-east"},
+		{"israel", "middle-east"},
+		{"saudi", "middle-east"},
+		{"brazil", "south-america"},
+		{"chile", "south-america"},
+		{"mexico", "north-america"},
+		{"canada", "north-america"},
+		{"asia", "asia"},
+		{"japan", "asia"},
+		{"korea", "asia"},
+		{"india", "asia"},
+		{"indonesia", "asia"},
+		{"malaysia", "asia"},
+		{"taiwan", "asia"},
+		{"china", "asia"},
+		{"europe", "europe"},
+		{"uk", "europe"},
+		{"france", "europe"},
+		{"germany", "europe"},
+		{"switzerland", "europe"},
+		{"norway", "europe"},
+		{"sweden", "europe"},
+		{"poland", "europe"},
+		{"italy", "europe"},
+		{"spain", "europe"},
+		{"austria", "europe"},
+		{"belgium", "europe"},
+		{"denmark", "europe"},
+		{"finland", "europe"},
+		{"greece", "europe"},
+		{"us", "north-america"},
+	}
+	for _, k := range keywords {
+		if strings.Contains(id, k.keyword) {
+			return k.continent
+		}
+	}
+
+	return "other"
+}
+
+// Returns the region of the table named `name` or whose id or display name
+// is `name`.
+func (t *LatencyTable) Region(name string) (string, bool) {
+	for _, r := range t.regions {
+		if r == name {
+			return r, true
+		}
+	}
+	for _, r := range t.regions {
+		if t.IdOf(r) == name {
+			return r, true
+		}
+	}
+	for _, r := range t.regions {
+		if n, exists := t.names[r]; exists && n == name {
+			return r, true
+		}
+	}
+	return "", false
+}
+
+func (t *LatencyTable) AddGroup(name string, rs []string) error {
+	g := &RegionGroup{Name: name}
+	for _, id := range rs {
+		r, exists := t.Region(id)
+		if !exists {
+			return errors.New("group " + name + ": unknown region " + id)
+		}
+		g.Regions = append(g.Regions, r)
+	}
+	for i, h := range t.groups {
+		if h.Name == name {
+			t.groups[i] = g
+			return nil
+		}
+	}
+	t.groups = append(t.groups, g)
+	return nil
+}
+
+// Loads region groups from a file with lines of the form
+//
+//	name region...
+func (t *LatencyTable) LoadGroups(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		data := strings.Fields(s.Text())
+		if len(data) > 0 && data[0] == "group" {
+			data = data[1:]
+		}
+		if len(data) < 2 || strings.HasPrefix(data[0], "#") {
+			continue
+		}
+		if err := t.AddGroup(data[0], data[1:]); err != nil {
+			return err
+		}
+	}
+	return s.Err()
+}
+
+// Returns user-defined groups followed by continent groups of the regions
+// not covered by any user-defined group and a group of client sites.
+func (t *LatencyTable) Groups() []*RegionGroup {
+	gs := append([]*RegionGroup{}, t.groups...)
+	covered := map[string]struct{}{}
+	for _, g := range t.groups {
+		for _, r := range g.Regions {
+			covered[r] = struct{}{}
+		}
+	}
+
+	continents := map[string]*RegionGroup{}
+	sites := &RegionGroup{Name: "client sites"}
+	for _, r := range t.regions {
+		if _, exists := covered[r]; exists {
+			continue
+		}
+		if t.IsSite(r) {
+			sites.Regions = append(sites.Regions, r)
+			continue
+		}
+		c := Continent(r)
+		g, exists := continents[c]
+		if !exists {
+			g = &RegionGroup{Name: c}
+			continents[c] = g
+			gs = append(gs, g)
+		}
+		g.Regions = append(g.Regions, r)
+	}
+	if len(sites.Regions) > 0 {
+		gs = append(gs, sites)
+	}
+	return gs
+}
+
+// Returns the regions of group `group`.
+func (t *LatencyTable) Group(group string) []string {
+	for _, g := range t.Groups() {
+		if g.Name == group {
+			return g.Regions
+		}
+	}
+	return nil
+}
+
+func (t *LatencyTable) InGroup(group, r string) bool {
+	return contains(t.Group(group), r)
+}
+
+// Returns a filter for quorums of size `size` that accepts only quorums
+// with at least one member of `group`. An empty group accepts any quorum.
+func (t *LatencyTable) GroupFilter(group string, size int) QuorumFilter {
+	if group == "" {
+		return NoFilter
+	}
+	members := map[string]struct{}{}
+	for _, r := range t.Group(group) {
+		members[r] = struct{}{}
+	}
+	return func(r string, rs []string) bool {
+		if len(rs) < size-1 {
+			return true
+		}
+		if _, exists := members[r]; exists {
+			return true
+		}
+		for _, r := range rs {
+			if _, exists := members[r]; exists {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Reads a square matrix of round-trip latencies (ms). The first row lists
+// destination regions, the first cell of every other row is its source
+// region. Region order of the first row is preserved.
+func ReadMatrix(r io.Reader, comma rune) (*LatencyTable, error) {
+	cr := csv.NewReader(r)
+	cr.Comma = comma
+	cr.FieldsPerRecord = -1
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < 2 || len(rows[0]) < 2 {
+		return nil, errors.New("empty latency matrix")
+	}
+
+	t := newLatencyTable()
+	for _, r := range rows[0][1:] {
+		r = NormalizeRegion(r)
+		if _, exists := t.latency[r]; exists || r == "" {
+			return nil, errors.New(fmt.Sprintf("invalid or duplicate region %q", r))
+		}
+		t.addRegion(r)
+	}
+	dsts := append([]string{}, t.regions...)
+
+	for i, row := range rows[1:] {
+		if len(row) > len(dsts)+1 {
+			err := fmt.Sprintf("row %d has %d cells, expected at most %d", i+2, len(row), len(dsts)+1)
+			return nil, errors.New(err)
+		}
+		r1 := NormalizeRegion(row[0])
+		if r1 == "" {
+			continue
+		}
+		if _, exists := t.latency[r1]; !exists {
+			t.addRegion(r1)
+		}
+		for j, cell := range row[1:] {
+			cell = strings.TrimSuffix(strings.TrimSpace(cell), "ms")
+			if cell == "" {
+				continue
+			}
+			// estimated latencies are prefixed with "~"
+			cell, estimated := strings.CutPrefix(cell, "~")
+			l, err := strconv.ParseFloat(strings.TrimSpace(cell), 64)
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("row %d: %v", i+2, err))
+			}
+			t.latency[r1][dsts[j]] = l
+			if estimated {
+				t.estimated[link{r1, dsts[j]}] = struct{}{}
+			}
+		}
+	}
+	return t, nil
+}
+
+// Writes round-trip latencies between `rs` as a square matrix, one
+// direction per cell. Missing links are written as empty cells, estimated
+// ones are prefixed with "~".
+func (t *LatencyTable) WriteMatrix(w io.Writer, rs []string, comma rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+
+	row := make([]string, len(rs)+1)
+	for i, r := range rs {
+		row[i+1] = t.IdOf(r)
+	}
+	if err := cw.Write(row); err != nil {
+		return err
+	}
+	for _, r1 := range rs {
+		row[0] = t.IdOf(r1)
+		for i, r2 := range rs {
+			row[i+1] = ""
+			if l, exists := t.latency[r1][r2]; exists {
+				row[i+1] = FormatMs(l)
+				if t.IsEstimated(r1, r2) {
+					row[i+1] = "~" + row[i+1]
+				}
+			}
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (t *LatencyTable) HasLink(r1, r2 string) bool {
+	if r1 == r2 {
+		return true
+	}
+	if _, exists := t.latency[r1][r2]; exists {
+		return true
+	}
+	_, exists := t.latency[r2][r1]
+	return exists
+}
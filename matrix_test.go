@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const matrix = `,us-east-1,eu-west-1,ap-south-1
+us-east-1,0,71.5,~190.25
+eu-west-1,71.5,0,
+ap-south-1,~190.25,,0
+`
+
+// asymmetric links and non-zero self latencies
+const skewed = `,us-east-1,eu-west-1
+us-east-1,0.5,70
+eu-west-1,~73,
+`
+
+func TestMatrixRoundTrip(t *testing.T) {
+	for _, comma := range []rune{',', '\t'} {
+		in := matrix
+		if comma == '\t' {
+			in = strings.ReplaceAll(in, ",", "\t")
+		}
+		t1, err := ReadMatrix(strings.NewReader(in), comma)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if t1.HasLink("eu-west-1", "ap-south-1") {
+			t.Error("empty cell read as a link")
+		}
+		if !t1.IsEstimated("us-east-1", "ap-south-1") || t1.IsEstimated("us-east-1", "eu-west-1") {
+			t.Error("estimated marks not read")
+		}
+
+		var b bytes.Buffer
+		if err := t1.WriteMatrix(&b, t1.regions, comma); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != in {
+			t.Errorf("wrote\n%v\nwant\n%v", b.String(), in)
+		}
+		t2, err := ReadMatrix(&b, comma)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(t1.regions, t2.regions) || !reflect.DeepEqual(t1.latency, t2.latency) ||
+			!reflect.DeepEqual(t1.estimated, t2.estimated) {
+			t.Errorf("round trip changed the table: %v, want %v", t2.latency, t1.latency)
+		}
+	}
+}
+
+func TestSkewedMatrixRoundTrip(t *testing.T) {
+	t1, err := ReadMatrix(strings.NewReader(skewed), ',')
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := t1.WriteMatrix(&b, t1.regions, ','); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != skewed {
+		t.Errorf("wrote\n%v\nwant\n%v", b.String(), skewed)
+	}
+}
+
+func TestReadMatrixErrors(t *testing.T) {
+	for _, in := range []string{
+		"",
+		",us-east-1,us-east-1\nus-east-1,0,0\n",
+		",us-east-1\nus-east-1,0,1\n",
+		",us-east-1\nus-east-1,fast\n",
+	} {
+		if _, err := ReadMatrix(strings.NewReader(in), ','); err == nil {
+			t.Errorf("no error reading %q", in)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// MergePolicy resolves conflicts between the links of a table and those
+// of the tables merged before it.
+type MergePolicy int
+
+const (
+	// links of earlier tables take precedence
+	MergeFirst MergePolicy = iota
+	// links of the table take precedence
+	MergeLast
+	MergeMin
+	MergeMax
+	MergeMean
+)
+
+func ParseMergePolicy(s string) (MergePolicy, error) {
+	switch s {
+	case "first":
+		return MergeFirst, nil
+	case "last":
+		return MergeLast, nil
+	case "min":
+		return MergeMin, nil
+	case "max":
+		return MergeMax, nil
+	case "mean":
+		return MergeMean, nil
+	}
+	return MergeFirst, errors.New("unknown merge policy " + s + ", expected first, last, min, max or mean")
+}
+
+// Parses either a single merge policy applied to `n` tables or a
+// comma-separated list of `n` policies, one per table.
+func ParseMergePolicies(s string, n int) ([]MergePolicy, error) {
+	names := strings.Split(s, ",")
+	if len(names) != 1 && len(names) != n {
+		return nil, errors.New(fmt.Sprintf("%d merge policies for %d sources", len(names), n))
+	}
+	ps := make([]MergePolicy, n)
+	for i := range ps {
+		name := names[0]
+		if len(names) == n {
+			name = names[i]
+		}
+		p, err := ParseMergePolicy(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		ps[i] = p
+	}
+	return ps, nil
+}
+
+// Merges latency tables. Regions are matched by name and keep the order
+// of their first appearance. Measured links always take precedence over
+// estimated ones, otherwise conflicts between table `ts[i]` and the tables
+// before it are resolved by `policies[i]`.
+func Merge(ts []*LatencyTable, policies []MergePolicy) *LatencyTable {
+	m := newLatencyTable()
+	count := map[link]int{}
+
+	for i, t := range ts {
+		policy := policies[i]
+		for _, r := range t.regions {
+			if _, exists := m.latency[r]; !exists {
+				m.addRegion(r)
+			}
+			if lm, exists := t.sites[r]; exists {
+				if _, exists := m.sites[r]; !exists || policy != MergeFirst {
+					m.sites[r] = lm
+				}
+			}
+			if name, exists := t.names[r]; exists {
+				if _, exists := m.names[r]; !exists || policy != MergeFirst {
+					m.names[r] = name
+				}
+			}
+			if c, exists := t.coords[r]; exists {
+				if _, exists := m.coords[r]; !exists || policy != MergeFirst {
+					m.coords[r] = c
+				}
+			}
+		}
+		for _, g := range t.groups {
+			exists := false
+			for _, h := range m.groups {
+				exists = exists || h.Name == g.Name
+			}
+			if !exists || policy != MergeFirst {
+				m.AddGroup(g.Name, g.Regions)
+			}
+		}
+
+		for r1, ls := range t.latency {
+			for r2, l := range ls {
+				k := link{r1, r2}
+				old, exists := m.latency[r1][r2]
+				_, oldEstimated := m.estimated[k]
+				estimated := t.IsEstimated(r1, r2)
+
+				switch {
+				case !exists || (oldEstimated && !estimated):
+					m.latency[r1][r2] = l
+					count[k] = 1
+				case estimated && !oldEstimated:
+					continue
+				case policy == MergeLast:
+					m.latency[r1][r2] = l
+					count[k] = 1
+				case policy == MergeMin:
+					m.latency[r1][r2] = math.Min(old, l)
+				case policy == MergeMax:
+					m.latency[r1][r2] = math.Max(old, l)
+				case policy == MergeMean:
+					m.latency[r1][r2] = (old*float64(count[k]) + l) / float64(count[k]+1)
+					count[k]++
+				}
+				if estimated {
+					m.estimated[k] = struct{}{}
+				} else {
+					delete(m.estimated, k)
+				}
+			}
+		}
+	}
+	return m
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Partition is a set of sides, regions of different sides cannot
+// communicate. Regions that belong to no side reach every region.
+type Partition []Quorum
+
+// current network partition
+var Partitioned = Partition{}
+
+func (p Partition) side(r string) int {
+	for i, s := range p {
+		if _, exists := s[r]; exists {
+			return i
+		}
+	}
+	return -1
+}
+
+func Reachable(r1, r2 string) bool {
+	s1, s2 := Partitioned.side(r1), Partitioned.side(r2)
+	return s1 == -1 || s2 == -1 || s1 == s2
+}
+
+// Filter of alive replicas reachable from every region of `from`.
+func ReachableFilter(from ...string) QuorumFilter {
+	return func(r string, _ []string) bool {
+		if IsCrashed(r) {
+			return false
+		}
+		for _, f := range from {
+			if !Reachable(f, r) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Alive replicas of `rs` reachable from `from`.
+func ReachableFrom(from string, rs []string) []string {
+	var as []string
+	for _, r := range rs {
+		if ReachableFilter(from)(r, nil) {
+			as = append(as, r)
+		}
+	}
+	return as
+}
+
+func (p Partition) String() string {
+	if len(p) == 0 {
+		return "none"
+	}
+	var ss []string
+	for _, s := range p {
+		ss = append(ss, strings.ReplaceAll(s.String(), "\n", ","))
+	}
+	return strings.Join(ss, " | ")
+}
+
+// Parses partitions separated by ";", whose sides are separated by "|"
+// and contain comma-separated regions, e.g., "us-east-1,us-west-2 | eu-west-1".
+func (t *LatencyTable) ParsePartitions(s string) ([]Partition, error) {
+	var ps []Partition
+	for _, spec := range strings.Split(s, ";") {
+		var p Partition
+		seen := Quorum{}
+		for _, side := range strings.Split(spec, "|") {
+			rs, unknown := t.ParseRegions(side)
+			if len(unknown) != 0 {
+				return nil, errors.New("unknown regions " + strings.Join(unknown, ", "))
+			}
+			for _, r := range rs {
+				if _, exists := seen[r]; exists {
+					return nil, errors.New(t.IdOf(r) + " belongs to several sides")
+				}
+				seen[r] = struct{}{}
+			}
+			if len(rs) != 0 {
+				p = append(p, QuorumOfSlice(rs))
+			}
+		}
+		if len(p) != 0 {
+			ps = append(ps, p)
+		}
+	}
+	return ps, nil
+}
+
+// Runs `f` under partition `p`.
+func WithPartition(p Partition, f func()) {
+	old := Partitioned
+	Partitioned = p
+	defer func() {
+		Partitioned = old
+	}()
+	f()
+}
+
+// Quorum of `size` replicas of `rs` reachable from `from` with the lowest
+// round trip from `from`, and that round trip.
+func ClosestQuorum(t *LatencyTable, rs []string, from string, size int) (Quorum, float64) {
+	var closest Quorum
+	m := math.Inf(1)
+	for _, q := range QuorumsOfSize(size, rs, ReachableFilter(from)) {
+		qm := 0.0
+		for r := range q {
+			qm = math.Max(qm, Mul(t.OneWayLatency(from, r), 2))
+		}
+		if qm < m {
+			closest, m = q, qm
+		}
+	}
+	return closest, m
+}
+
+// Describes which clients `cs` can commit under each protocol over
+// replicas `rs`, through which leader and quorum, and at what latency.
+func PartitionReport(t *LatencyTable, rs, cs []string) string {
+	s := "partition: " + Partitioned.String() + "\n"
+	for _, p := range Protocols(t, rs, cs) {
+		s += "\n" + p.Name + ":\n"
+		if p.Leader != "<leaderless>" {
+			if p.Leader == "" {
+				s += "  no leader\n"
+				continue
+			}
+			q, _ := ClosestQuorum(t, rs, p.Leader, len(rs)/2+1)
+			s += "  leader: " + t.IdOf(p.Leader) + ", quorum: " + quorumIds(t, q) + "\n"
+		}
+		for _, c := range cs {
+			l := Average(p.Alg, []string{c}, true)
+			if math.IsInf(l, 1) {
+				s += "  " + t.IdOf(c) + ": cannot commit\n"
+				continue
+			}
+			s += fmt.Sprintf("  %v: %0.3f", t.IdOf(c), l)
+			if p.Leader == "<leaderless>" {
+				s += " via " + t.IdOf(Client(c).ClosestReplica(ReachableFrom(c, rs), t))
+			}
+			s += "\n"
+		}
+	}
+	return s
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPartitionedProtocols(t *testing.T) {
+	lt, rs, cs := lineTable(t)
+	ps, err := lt.ParsePartitions("a,d|b,c,e")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	WithPartition(ps[0], func() {
+		if l := lt.OneWayLatency("a", "b"); l != 5 {
+			t.Errorf("one-way latency across the partition is %v, want 5", l)
+		}
+		for _, p := range Protocols(lt, rs, cs) {
+			for _, c := range cs {
+				l := p.Alg.Accept(c, true)
+				// f belongs to no side and reaches the majority
+				minority := c == "a" || c == "d"
+				if minority != math.IsInf(l, 1) {
+					t.Errorf("%v: latency of %v is %v", p.Name, c, l)
+				}
+			}
+		}
+	})
+}
@@ -26,13 +26,21 @@ func (p *Paxos) GetReplicas() []string {
 	return p.rs
 }
 
+// Returns +Inf if there is no alive leader reachable from `c` or no majority
+// of replicas is alive and reachable.
 func (p *Paxos) Accept(c string, _ bool) float64 {
+	if p.leader == "" || IsCrashed(p.leader) || !Reachable(c, p.leader) {
+		return math.Inf(1)
+	}
 	closest := p.leader
 	if p.n2 {
-		closest = Client(c).ClosestReplica(p.rs, p.latency)
+		closest = Client(c).ClosestReplica(ReachableFrom(c, p.rs), p.latency)
+		if closest == "" {
+			return math.Inf(1)
+		}
 	}
 	m := math.Inf(1)
-	slowQs := QuorumsOfSize(len(p.rs)/2+1, p.rs, NoFilter)
+	slowQs := QuorumsOfSize(len(p.rs)/2+1, p.rs, ReachableFilter(p.leader, closest))
 	for _, q := range slowQs {
 		qm := 0.0
 		for r := range q {
@@ -44,20 +52,76 @@ func (p *Paxos) Accept(c string, _ bool) float64 {
 	return Round(m + p.latency.OneWayLatency(closest, c))
 }
 
+// The leader batches the command and acceptors persist it before replying.
 func (p *Paxos) m2b(client, replica, closest string) float64 {
-	l1 := p.latency.OneWayLatency(client, p.leader)
-	l2 := p.latency.OneWayLatency(p.leader, replica)
+	l1 := p.latency.OneWayLatency(client, p.leader) + ProcessingDelay(p.leader) + BatchWait()
+	l2 := p.latency.OneWayLatency(p.leader, replica) + PersistDelay(replica)
 	l3 := p.latency.OneWayLatency(replica, closest)
 	return Round(l1 + l2 + l3)
 }
 
+// The leader forwards the command to replicas, which acknowledge it to the
+// leader (Paxos) or to every replica (N²Paxos). Paxos' leader then commits it.
+func (p *Paxos) Messages(c string, _ bool) []Message {
+	if p.leader == "" || IsCrashed(p.leader) {
+		return nil
+	}
+	rs := Alive(p.rs)
+	ms := send(nil, c, []string{p.leader}, CommandSize)
+	ms = send(ms, p.leader, rs, CommandSize)
+	if p.n2 {
+		for _, r := range rs {
+			ms = send(ms, r, rs, AckSize)
+		}
+		return send(ms, Client(c).ClosestReplica(rs, p.latency), []string{c}, AckSize)
+	}
+	for _, r := range rs {
+		ms = send(ms, r, []string{p.leader}, AckSize)
+	}
+	ms = send(ms, p.leader, []string{c}, AckSize)
+	return send(ms, p.leader, rs, AckSize)
+}
+
+func (p *Paxos) Flow(c string, _ bool) []*Hop {
+	if p.leader == "" || IsCrashed(p.leader) || !Reachable(c, p.leader) {
+		return nil
+	}
+	closest := p.leader
+	if p.n2 {
+		closest = Client(c).ClosestReplica(ReachableFrom(c, p.rs), p.latency)
+		if closest == "" {
+			return nil
+		}
+	}
+	req := hop(p.latency, c, p.leader, "request", 0)
+	start := req.Arrive + ProcessingDelay(p.leader) + BatchWait()
+	hs := []*Hop{req}
+	var acks []*Hop
+	for _, r := range p.rs {
+		if !ReachableFilter(p.leader, closest)(r, nil) {
+			continue
+		}
+		a := hop(p.latency, p.leader, r, "accept", start)
+		if r != p.leader {
+			hs = append(hs, a)
+		}
+		acks = append(acks, hop(p.latency, r, closest, "ack", a.Arrive+PersistDelay(r)))
+	}
+	last := quorumLast(acks, len(p.rs)/2+1)
+	if last == nil {
+		return nil
+	}
+	hs = append(hs, acks...)
+	return append(hs, hop(p.latency, closest, c, "reply", last.Arrive))
+}
+
 func (p *Paxos) SetAverageBestLeader(cs []string) (string, float64) {
 	min := math.Inf(1)
 	leader := ""
 
-	for _, r := range p.rs {
+	for _, r := range Alive(p.rs) {
 		p.leader = r
-		l := Average(p, cs, true)
+		l := Cost(p, cs, true)
 		if l < min {
 			min = l
 			leader = r
@@ -2,11 +2,15 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -18,22 +22,110 @@ type LatencyTable struct {
 	regions []string
 	latency map[string]map[string]float64
 
-	us     map[string]struct{}
-	asia   map[string]struct{}
-	europe map[string]struct{}
+	// user-defined region groups
+	groups []*RegionGroup
+	// display names of regions
+	names map[string]string
+	// explicitly set region coordinates
+	coords map[string]Coord
+	// links that were not measured
+	estimated map[link]struct{}
+	// client sites and their last-mile latencies
+	sites map[string]float64
+
+	// time of the cloudping scrape (zero for local tables)
+	fetched time.Time
 }
 
-func NewLatencyTable() (*LatencyTable, error) {
-	t := &LatencyTable{
+func newLatencyTable() *LatencyTable {
+	return &LatencyTable{
 		regions: []string{},
 		latency: make(map[string]map[string]float64),
 
-		us:     make(map[string]struct{}),
-		asia:   make(map[string]struct{}),
-		europe: make(map[string]struct{}),
+		names:     make(map[string]string),
+		coords:    make(map[string]Coord),
+		estimated: make(map[link]struct{}),
+		sites:     make(map[string]float64),
+	}
+}
+
+func (t *LatencyTable) addRegion(r string) {
+	t.regions = append(t.regions, r)
+	t.latency[r] = make(map[string]float64)
+}
+
+// Returns a deep copy of `t`.
+func (t *LatencyTable) Copy() *LatencyTable {
+	c := newLatencyTable()
+	for _, r := range t.regions {
+		c.addRegion(r)
+		for r2, l := range t.latency[r] {
+			c.latency[r][r2] = l
+		}
+	}
+	for _, g := range t.groups {
+		c.groups = append(c.groups, &RegionGroup{g.Name, append([]string{}, g.Regions...)})
+	}
+	for r, name := range t.names {
+		c.names[r] = name
+	}
+	for r, coord := range t.coords {
+		c.coords[r] = coord
+	}
+	for l := range t.estimated {
+		c.estimated[l] = struct{}{}
+	}
+	for s, l := range t.sites {
+		c.sites[s] = l
+	}
+	c.fetched = t.fetched
+	return c
+}
+
+// Scrapes the cloudping grid for the given percentile (e.g., "p_50") and
+// timeframe (e.g., "1D"). Empty values select the cloudping defaults.
+// Successfully parsed tables are cached for the lifetime of the process
+// and on disk, and every caller gets its own copy. The disk cache is used
+// if cloudping is unreachable, or instead of cloudping, depending on `mode`.
+func NewLatencyTable(percentile, timeframe string, mode CacheMode) (*LatencyTable, error) {
+	url, err := CloudpingURL(percentile, timeframe)
+	if err != nil {
+		return nil, err
+	}
+	if t, exists := cloudpingCache[url]; exists && mode != CacheRefresh {
+		return t.Copy(), nil
+	}
+
+	if mode == CacheOffline {
+		cached, err := LoadCachedTable(url)
+		if err != nil {
+			return nil, errors.New("no cached latency table for " + url + ": " + err.Error())
+		}
+		cloudpingCache[url] = cached
+		return cached.Copy(), nil
+	}
+
+	t, err := fetchCloudping(url)
+	if err != nil {
+		if mode == CacheDefault {
+			if cached, cerr := LoadCachedTable(url); cerr == nil {
+				fmt.Fprintln(os.Stderr, err.Error()+", using cloudping data of "+cached.fetched.Format(time.RFC1123))
+				cloudpingCache[url] = cached
+				return cached.Copy(), nil
+			}
+		}
+		return nil, err
 	}
+	t.fetched = time.Now()
+	cloudpingCache[url] = t
+	if err := SaveCachedTable(url, t); err != nil {
+		fmt.Fprintln(os.Stderr, "cannot cache cloudping data: "+err.Error())
+	}
+	return t.Copy(), nil
+}
 
-	res, err := http.Get(cloudping)
+func fetchCloudping(url string) (*LatencyTable, error) {
+	res, err := http.Get(url)
 	if err != nil {
 		return nil, err
 	}
@@ -43,21 +135,21 @@ func NewLatencyTable() (*LatencyTable, error) {
 		return nil, errors.New(err)
 	}
 
-	doc, err := goquery.NewDocumentFromReader(res.Body)
+	return ParseCloudping(res.Body)
+}
+
+// Parses an HTML page of cloudping's latency grid.
+func ParseCloudping(r io.Reader) (*LatencyTable, error) {
+	t := newLatencyTable()
+
+	doc, err := goquery.NewDocumentFromReader(r)
 	if err != nil {
 		return nil, err
 	}
 
 	doc.Find("th").Each(func(_ int, s *goquery.Selection) {
-		if region := s.Text(); region != "To \\ From" {
-			t.regions = append(t.regions, region)
-			if strings.Contains(region, " us-") {
-				t.us[region] = struct{}{}
-			} else if strings.Contains(region, " ap-") {
-				t.asia[region] = struct{}{}
-			} else if strings.Contains(region, " eu-") {
-				t.europe[region] = struct{}{}
-			}
+		if region := strings.TrimSpace(s.Text()); region != "To \\ From" {
+			t.addRegion(region)
 		}
 	})
 
@@ -65,45 +157,62 @@ func NewLatencyTable() (*LatencyTable, error) {
 		return nil, errors.New("Something went wrong. It's likely that Cloudping was updated and is currently unsupported.")
 	}
 
-	for _, region := range t.regions {
-		t.latency[region] = make(map[string]float64)
-	}
-
 	i := 0
 	doc.Find("td").Each(func(_ int, s *goquery.Selection) {
-		l, found := strings.CutSuffix(s.Text(), "ms")
-		if found {
+		l, found := strings.CutSuffix(strings.TrimSpace(s.Text()), "ms")
+		if found && i < len(t.regions)*len(t.regions) {
 			r1, r2 := t.regions[i/len(t.regions)], t.regions[i%len(t.regions)]
-			t.latency[r1][r2], _ = strconv.ParseFloat(l, 64)
+			t.latency[r1][r2], _ = strconv.ParseFloat(strings.TrimSpace(l), 64)
 			i++
 		}
 	})
 
+	if i != len(t.regions)*len(t.regions) {
+		err := fmt.Sprintf("cloudping grid is incomplete: %d of %d cells parsed", i, len(t.regions)*len(t.regions))
+		return nil, errors.New(err)
+	}
+
 	return t, nil
 }
 
 func NewLatencyTableFromFile(latencyConf string) (*LatencyTable, error) {
-	t := &LatencyTable{
-		regions: []string{},
-		latency: make(map[string]map[string]float64),
-
-		us:     make(map[string]struct{}),
-		asia:   make(map[string]struct{}),
-		europe: make(map[string]struct{}),
-	}
-
 	lf, err := os.Open(latencyConf)
 	if err != nil {
 		return nil, err
 	}
 	defer lf.Close()
 
-	s := bufio.NewScanner(lf)
+	return ReadLatencyTable(lf)
+}
+
+// Reads a latency table in flint's `src dst latency` format.
+func ReadLatencyTable(r io.Reader) (*LatencyTable, error) {
+	t := newLatencyTable()
+
+	var groups, coords, sites [][]string
+	s := bufio.NewScanner(r)
 	for s.Scan() {
 		data := strings.Fields(s.Text())
+		if len(data) > 0 && strings.HasPrefix(data[0], "#") {
+			// header directives: `# group name region...`,
+			// `# coord region latitude longitude` and `# site name last-mile`
+			if len(data) > 2 && data[0] == "#" && data[1] == "group" {
+				groups = append(groups, data[2:])
+			} else if len(data) == 5 && data[0] == "#" && data[1] == "coord" {
+				coords = append(coords, data[2:])
+			} else if len(data) == 4 && data[0] == "#" && data[1] == "site" {
+				sites = append(sites, data[2:])
+			}
+			continue
+		}
+		estimated := false
+		if len(data) == 5 && data[3] == "#" && data[4] == "estimated" {
+			data, estimated = data[:3], true
+		}
 		if len(data) != 3 {
 			continue
 		}
+		data[0], data[1] = NormalizeRegion(data[0]), NormalizeRegion(data[1])
 		add1, add2 := true, data[0] != data[1]
 		for _, r := range t.regions {
 			if add1 && r == data[0] {
@@ -114,51 +223,67 @@ func NewLatencyTableFromFile(latencyConf string) (*LatencyTable, error) {
 			}
 		}
 		if add1 {
-			t.regions = append(t.regions, data[0])
-			t.latency[data[0]] = make(map[string]float64)
-			if strings.Contains(data[0], "us-") {
-				t.us[data[0]] = struct{}{}
-			} else if strings.Contains(data[0], "ap-") {
-				t.asia[data[0]] = struct{}{}
-			} else if strings.Contains(data[0], "eu-") {
-				t.europe[data[0]] = struct{}{}
-			}
+			t.addRegion(data[0])
 		}
 		if add2 {
-			t.regions = append(t.regions, data[1])
-			t.latency[data[1]] = make(map[string]float64)
-			if strings.Contains(data[1], "us-") {
-				t.us[data[1]] = struct{}{}
-			} else if strings.Contains(data[1], "ap-") {
-				t.asia[data[1]] = struct{}{}
-			} else if strings.Contains(data[1], "eu-") {
-				t.europe[data[1]] = struct{}{}
-			}
+			t.addRegion(data[1])
 		}
 		d, err := time.ParseDuration(data[2])
 		if err != nil {
 			return nil, err
 		}
-		t.latency[data[0]][data[1]] = float64(d.Milliseconds())
+		t.latency[data[0]][data[1]] = float64(d) / float64(time.Millisecond)
+		if estimated {
+			t.estimated[link{data[0], data[1]}] = struct{}{}
+		}
+	}
+	for _, site := range sites {
+		d, err := time.ParseDuration(site[1])
+		if err != nil {
+			return nil, err
+		}
+		t.AddSite(site[0], float64(d)/float64(time.Millisecond))
+	}
+	for _, c := range coords {
+		coord, err := parseCoord(c[1], c[2])
+		if err != nil {
+			return nil, err
+		}
+		t.SetCoord(NormalizeRegion(c[0]), coord)
+	}
+	for _, g := range groups {
+		if err := t.AddGroup(g[0], g[1:]); err != nil {
+			return nil, err
+		}
 	}
 	return t, nil
 }
 
+// Includes last-mile latencies of client sites. Network partitions are
+// ignored, protocols only use links between reachable regions.
 func (t *LatencyTable) OneWayLatency(r1, r2 string) float64 {
 	if r1 == r2 {
 		return 0.0
 	}
+	return Round(t.oneWay(r1, r2) + t.sites[r1] + t.sites[r2])
+}
+
+// Same as OneWayLatency, but without rounding and last-mile latencies.
+func (t *LatencyTable) oneWay(r1, r2 string) float64 {
+	if r1 == r2 {
+		return 0.0
+	}
 
 	ls1, exists1 := t.latency[r1]
 	ls2, exists2 := t.latency[r2]
 	if exists1 && !exists2 {
 		if l, exists := ls1[r2]; exists {
-			return Div(l, 2.0)
+			return l / 2.0
 		}
 		return 0.0
 	} else if exists2 && !exists1 {
 		if l, exists := ls2[r1]; exists {
-			return Div(l, 2.0)
+			return l / 2.0
 		}
 		return 0.0
 	}
@@ -166,11 +291,11 @@ func (t *LatencyTable) OneWayLatency(r1, r2 string) float64 {
 	l1, exists1 := ls1[r2]
 	l2, exists2 := ls2[r1]
 	if exists1 && exists2 {
-		return Div(Div(l1, 2.0)+Div(l2, 2.0), 2.0)
+		return (l1 + l2) / 4.0
 	} else if exists1 {
-		return Div(l1, 2.0)
+		return l1 / 2.0
 	} else if exists2 {
-		return Div(l2, 2.0)
+		return l2 / 2.0
 	}
 
 	return 0.0
@@ -187,6 +312,9 @@ func (t *LatencyTable) IdOf(r string) string {
 }
 
 func (t *LatencyTable) Site(r string) string {
+	if name, exists := t.names[r]; exists {
+		return name
+	}
 	for _, region := range t.regions {
 		if r == region {
 			s := strings.Split(region, "(")
@@ -210,10 +338,14 @@ func (t *LatencyTable) String() string {
 		s += fmt.Sprintln(region, ":")
 		j := 0
 		for dregion, l := range ls {
+			e := ""
+			if t.IsEstimated(region, dregion) {
+				e = " (estimated)"
+			}
 			if i == len(t.latency)-1 && j == len(ls)-1 {
-				s += fmt.Sprintf("---  %v %v", dregion, l)
+				s += fmt.Sprintf("---  %v %v%v", dregion, l, e)
 			} else {
-				s += fmt.Sprintln("--- ", dregion, l)
+				s += fmt.Sprintln("--- ", dregion, fmt.Sprint(l)+e)
 			}
 			j++
 		}
@@ -227,15 +359,34 @@ func (t *LatencyTable) String() string {
 
 func (t *LatencyTable) StringOf(rs []string) string {
 	s := ""
+	for _, r := range rs {
+		if t.IsSite(r) {
+			s += fmt.Sprintf("# site %v %vms\n", t.IdOf(r), FormatMs(t.sites[r]))
+		}
+	}
+	for _, r := range rs {
+		if c, exists := t.coords[r]; exists {
+			s += fmt.Sprintf("# coord %v %v\n", t.IdOf(r), c)
+		}
+	}
 	for _, r1 := range rs {
 		for _, r2 := range rs {
-			s += fmt.Sprintf("%v %v %0.0fms\n",
-				t.IdOf(r1), t.IdOf(r2), Mul(t.OneWayLatency(r1, r2), 2))
+			if !t.HasLink(r1, r2) {
+				continue
+			}
+			s += fmt.Sprintf("%v %v %vms", t.IdOf(r1), t.IdOf(r2), FormatMs(2*t.oneWay(r1, r2)))
+			if t.IsEstimated(r1, r2) || t.IsEstimated(r2, r1) {
+				s += " # estimated"
+			}
+			s += "\n"
 		}
 	}
 	return s
 }
 
+// Exports latencies between `rs` into `filename`. The format is chosen by
+// the extension: ".csv" and ".tsv" produce square matrices, ".json" the
+// format of JSONSource, anything else flint's `src dst latency` format.
 func (t *LatencyTable) Export(rs []string, filename string) error {
 	nrs := []string{}
 	seen := map[string]struct{}{}
@@ -247,5 +398,23 @@ func (t *LatencyTable) Export(rs []string, filename string) error {
 		}
 	}
 
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv", ".tsv":
+		comma := ','
+		if strings.ToLower(filepath.Ext(filename)) == ".tsv" {
+			comma = '\t'
+		}
+		var b bytes.Buffer
+		if err := t.WriteMatrix(&b, nrs, comma); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(filename, b.Bytes(), 0644)
+	case ".json":
+		data, err := json.MarshalIndent(t.jsonTable(nrs, t.IdOf), "", "  ")
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(filename, append(data, '\n'), 0644)
+	}
 	return ioutil.WriteFile(filename, []byte(t.StringOf(nrs)), 0644)
 }
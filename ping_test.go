@@ -0,0 +1,139 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func parseFixture(t *testing.T, name string) (*LatencyTable, error) {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	return ParseCloudping(f)
+}
+
+func TestParseCloudping(t *testing.T) {
+	regions := []string{
+		"US East (N. Virginia) us-east-1",
+		"Europe (Ireland) eu-west-1",
+		"Asia Pacific (Mumbai) ap-south-1",
+		"South America (São Paulo) sa-east-1",
+	}
+	tests := []struct {
+		fixture string
+		links   map[link]float64
+	}{
+		{"cloudping_p_50_1D.html", map[link]float64{
+			{regions[0], regions[0]}: 6.12,
+			{regions[0], regions[1]}: 71.48,
+			{regions[1], regions[0]}: 70.91,
+			{regions[2], regions[3]}: 301.18,
+			{regions[3], regions[3]}: 4.21,
+		}},
+		{"cloudping_p_90_1W.html", map[link]float64{
+			{regions[0], regions[0]}: 9.87,
+			{regions[0], regions[1]}: 74.10,
+			{regions[1], regions[0]}: 73.64,
+			{regions[2], regions[3]}: 309.77,
+			{regions[3], regions[3]}: 7.36,
+		}},
+	}
+	for _, test := range tests {
+		lt, err := parseFixture(t, test.fixture)
+		if err != nil {
+			t.Fatalf("%v: %v", test.fixture, err)
+		}
+		if strings.Join(lt.regions, ";") != strings.Join(regions, ";") {
+			t.Errorf("%v: regions %v, want %v", test.fixture, lt.regions, regions)
+		}
+		for _, r := range regions {
+			if len(lt.latency[r]) != len(regions) {
+				t.Errorf("%v: %v has %d links, want %d", test.fixture, r, len(lt.latency[r]), len(regions))
+			}
+		}
+		for l, want := range test.links {
+			if got := lt.latency[l.r1][l.r2]; got != want {
+				t.Errorf("%v: %v -> %v is %v, want %v", test.fixture, l.r1, l.r2, got, want)
+			}
+		}
+	}
+}
+
+func TestParseCloudpingEmptyCell(t *testing.T) {
+	if _, err := parseFixture(t, "cloudping_empty_cell.html"); err == nil || !strings.Contains(err.Error(), "15 of 16") {
+		t.Errorf("expected an incomplete grid error, got %v", err)
+	}
+}
+
+func TestNewLatencyTableVariant(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		http.ServeFile(w, r, "testdata/cloudping_p_90_1W.html")
+	}))
+	defer server.Close()
+	defer func(url string) {
+		cloudping = url
+	}(cloudping)
+	cloudping = server.URL + "/"
+
+	lt, err := NewLatencyTable("p90", "1w", CacheDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requested) != 1 || requested[0] != "/grid/p_90/timeframe/1W" {
+		t.Errorf("requested %v, want [/grid/p_90/timeframe/1W]", requested)
+	}
+	if l := lt.latency["US East (N. Virginia) us-east-1"]["US East (N. Virginia) us-east-1"]; l != 9.87 {
+		t.Errorf("self latency of us-east-1 is %v, want 9.87", l)
+	}
+	if lt.fetched.IsZero() {
+		t.Error("scraped table has no fetch time")
+	}
+
+	lt.Rename("US East (N. Virginia) us-east-1", "us-east-1")
+	lt, err = NewLatencyTable("p90", "1w", CacheDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requested) != 1 || lt.regions[0] != "US East (N. Virginia) us-east-1" {
+		t.Errorf("cached table changed with the returned one: %v", lt.regions)
+	}
+}
+
+func TestNewLatencyTableFallback(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/cloudping_p_50_1D.html")
+	}))
+	defer func(url string) {
+		cloudping = url
+	}(cloudping)
+	cloudping = server.URL + "/"
+
+	if _, err := NewLatencyTable("p50", "1D", CacheDefault); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+	delete(cloudpingCache, server.URL+"/grid/p_50/timeframe/1D")
+
+	if _, err := NewLatencyTable("p50", "1D", CacheRefresh); err == nil {
+		t.Error("refresh succeeded without cloudping")
+	}
+	lt, err := NewLatencyTable("p50", "1D", CacheDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lt.fetched.IsZero() || len(lt.regions) != 4 {
+		t.Errorf("fallback table has %d regions, fetched %v", len(lt.regions), lt.fetched)
+	}
+}
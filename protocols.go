@@ -0,0 +1,81 @@
+package main
+
+import "strings"
+
+// Protocol is an algorithm with its optimal leader and fixed fast quorum
+// (if any) for some set of clients.
+type Protocol struct {
+	Name   string
+	Alg    Algorithm
+	Leader string
+	Quorum Quorum
+	// whether fast and slow paths differ
+	TwoPaths bool
+	// whether clients contact their closest replica
+	Closest bool
+	// size of fast quorums (0 if the fast quorum is fixed or there is none)
+	FastQuorumSize int
+}
+
+// Instantiates every supported protocol over replicas `rs` and chooses
+// their optimal leaders and quorums for clients `cs` among alive replicas.
+func Protocols(t *LatencyTable, rs, cs []string) []*Protocol {
+	sp := NewSwiftPaxos(rs, t)
+	c := NewCurpN2Paxos(rs, t)
+	p := NewPaxos(rs, t, false)
+	n := NewPaxos(rs, t, true)
+	a := NewAccord(rs, t)
+	quorumSp, leaderSp, _ := sp.SetAverageBestFixedQuorumAndLeader(cs, AliveFilter)
+	leaderC, _ := c.SetAverageBestLeader(cs)
+	leaderP, _ := p.SetAverageBestLeader(cs)
+	leaderN, _ := n.SetAverageBestLeader(cs)
+
+	return []*Protocol{
+		{Name: "SwiftPaxos", Alg: sp, Leader: leaderSp, Quorum: quorumSp, TwoPaths: true},
+		{Name: "CURP (N²Paxos)", Alg: c, Leader: leaderC, TwoPaths: true, Closest: true, FastQuorumSize: c.FastQuorumSize()},
+		{Name: "Paxos", Alg: p, Leader: leaderP},
+		{Name: "N²Paxos", Alg: n, Leader: leaderN, Closest: true},
+		{Name: "Accord", Alg: a, Leader: "<leaderless>", TwoPaths: true, FastQuorumSize: a.FastQuorumSize()},
+	}
+}
+
+// Moves `alg` to replicas `rs` and chooses its optimal leader and fixed
+// fast quorum (if any) for clients `cs`.
+func Reconfigure(alg Algorithm, rs, cs []string) {
+	alg.SetReplicas(rs)
+	switch a := alg.(type) {
+	case *SwiftPaxos:
+		a.SetAverageBestFixedQuorumAndLeader(cs, AliveFilter)
+	case *CurpN2Paxos:
+		a.SetAverageBestLeader(cs)
+	case *Paxos:
+		a.SetAverageBestLeader(cs)
+	}
+}
+
+// Returns the algorithms of `ps` except the one named `name`.
+func Others(ps []*Protocol, name string) []Algorithm {
+	var as []Algorithm
+	for _, p := range ps {
+		if p.Name != name {
+			as = append(as, p.Alg)
+		}
+	}
+	return as
+}
+
+// Resolves a comma-separated list of region names or ids.
+func (t *LatencyTable) ParseRegions(list string) ([]string, []string) {
+	var rs, unknown []string
+	for _, id := range strings.Split(list, ",") {
+		if id = strings.TrimSpace(id); id == "" {
+			continue
+		}
+		if r, exists := t.Region(id); exists {
+			rs = append(rs, r)
+		} else {
+			unknown = append(unknown, id)
+		}
+	}
+	return rs, unknown
+}
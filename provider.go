@@ -0,0 +1,92 @@
+package main
+
+import (
+	"embed"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+//go:embed data/*.txt
+var datasets embed.FS
+
+//go:embed latency_table_example.txt
+var awsDataset string
+
+var (
+	providers = []string{"aws", "gcp", "azure"}
+
+	// regions, optionally followed by a zone
+	awsZone = regexp.MustCompile(`^([a-z]{2}(-gov)?-[a-z]+-[0-9]+)[a-z]?$`)
+	gcpZone = regexp.MustCompile(`^([a-z]+-[a-z]+[0-9]+)(-[a-z])?$`)
+
+	azureRegions = []string{
+		"eastus", "eastus2", "centralus", "northcentralus", "southcentralus",
+		"westcentralus", "westus", "westus2", "westus3", "canadacentral",
+		"canadaeast", "brazilsouth", "mexicocentral", "northeurope",
+		"westeurope", "uksouth", "ukwest", "francecentral", "francesouth",
+		"germanywestcentral", "germanynorth", "switzerlandnorth",
+		"switzerlandwest", "norwayeast", "norwaywest", "swedencentral",
+		"polandcentral", "italynorth", "spaincentral", "japaneast",
+		"japanwest", "koreacentral", "koreasouth", "eastasia",
+		"southeastasia", "centralindia", "southindia", "westindia",
+		"australiaeast", "australiasoutheast", "australiacentral",
+		"southafricanorth", "southafricawest", "uaenorth", "uaecentral",
+		"qatarcentral", "israelcentral",
+	}
+)
+
+// Returns the canonical name of an AWS, GCP or Azure region:
+//
+//	us-east-1a     -> us-east-1          (AWS availability zone)
+//	US-EAST-1      -> us-east-1          (AWS region)
+//	us-central1-b  -> us-central1        (GCP zone)
+//	East US 2      -> eastus2            (Azure display name)
+//	GCP/europe-west1-c -> gcp/europe-west1
+//
+// Unrecognized names are returned unchanged.
+func NormalizeRegion(name string) string {
+	name = strings.TrimSpace(name)
+	prefix, id := "", name
+	if p, r, found := strings.Cut(name, "/"); found && contains(providers, strings.ToLower(p)) {
+		prefix, id = strings.ToLower(p)+"/", r
+	}
+	if strings.ContainsAny(id, "()") {
+		return name
+	}
+
+	lid := strings.ToLower(id)
+	if m := awsZone.FindStringSubmatch(lid); m != nil {
+		return prefix + m[1]
+	}
+	if m := gcpZone.FindStringSubmatch(lid); m != nil {
+		return prefix + m[1]
+	}
+	if compact := strings.Join(strings.Fields(lid), ""); contains(azureRegions, compact) {
+		return prefix + compact
+	}
+	return prefix + id
+}
+
+// BuiltinSource loads one of the sample latency tables shipped with flint:
+// "aws" (measured by cloudping), or "gcp-estimated", "azure-estimated" and
+// "multicloud-estimated", whose links are estimated from distances.
+type BuiltinSource struct {
+	Name string
+}
+
+func (s *BuiltinSource) Load() (*LatencyTable, error) {
+	if s.Name == "aws" {
+		return ReadLatencyTable(strings.NewReader(awsDataset))
+	}
+	f, err := datasets.Open("data/" + s.Name + ".txt")
+	if err != nil {
+		return nil, errors.New("unknown builtin latency table " + s.Name + ", expected aws, gcp-estimated, azure-estimated or multicloud-estimated")
+	}
+	defer f.Close()
+	return ReadLatencyTable(f)
+}
+
+func (s *BuiltinSource) String() string {
+	return "builtin:" + s.Name
+}
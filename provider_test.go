@@ -0,0 +1,63 @@
+package main
+
+import "testing"
+
+func TestNormalizeRegion(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		// AWS regions and availability zones
+		{"us-east-1", "us-east-1"},
+		{"us-east-1a", "us-east-1"},
+		{"ap-northeast-3c", "ap-northeast-3"},
+		{"us-gov-west-1b", "us-gov-west-1"},
+		{" eu-central-2a ", "eu-central-2"},
+		{"US-EAST-1A", "us-east-1"},
+		{"US-EAST-1", "us-east-1"},
+		// GCP regions and zones
+		{"us-central1", "us-central1"},
+		{"us-central1-b", "us-central1"},
+		{"US-Central1", "us-central1"},
+		{"europe-west1-c", "europe-west1"},
+		{"northamerica-northeast2-a", "northamerica-northeast2"},
+		// Azure region ids and display names
+		{"eastus2", "eastus2"},
+		{"East US 2", "eastus2"},
+		{"West Europe", "westeurope"},
+		{"Germany West Central", "germanywestcentral"},
+		{"southeastasia", "southeastasia"},
+		// provider prefixes
+		{"GCP/europe-west1-c", "gcp/europe-west1"},
+		{"aws/us-west-2b", "aws/us-west-2"},
+		{"azure/North Europe", "azure/northeurope"},
+		// unrecognized names
+		{"US East (N. Virginia) us-east-1", "US East (N. Virginia) us-east-1"},
+		{"my-datacenter", "my-datacenter"},
+		{"paris/rack-1", "paris/rack-1"},
+		{"West Moon", "West Moon"},
+	}
+	for _, test := range tests {
+		if got := NormalizeRegion(test.name); got != test.want {
+			t.Errorf("NormalizeRegion(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestBuiltinSources(t *testing.T) {
+	for _, name := range []string{"aws", "gcp-estimated", "azure-estimated", "multicloud-estimated"} {
+		lt, err := (&BuiltinSource{Name: name}).Load()
+		if err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+		if len(lt.regions) < 2 {
+			t.Errorf("%v has %d regions", name, len(lt.regions))
+		}
+		r1, r2 := lt.regions[0], lt.regions[1]
+		if estimated := lt.IsEstimated(r1, r2) || lt.IsEstimated(r2, r1); estimated != (name != "aws") {
+			t.Errorf("%v: link %v <-> %v estimated: %v", name, r1, r2, estimated)
+		}
+	}
+	if _, err := (&BuiltinSource{Name: "gcp"}).Load(); err == nil {
+		t.Error("loaded unknown builtin table gcp")
+	}
+}
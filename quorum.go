@@ -13,8 +13,31 @@ var (
 	NoFilter = func(string, []string) bool {
 		return true
 	}
+
+	// replicas considered crashed
+	Crashed = Quorum{}
+
+	AliveFilter = func(r string, _ []string) bool {
+		return !IsCrashed(r)
+	}
 )
 
+func IsCrashed(r string) bool {
+	_, crashed := Crashed[r]
+	return crashed
+}
+
+// Returns replicas of `rs` that are not crashed.
+func Alive(rs []string) []string {
+	var as []string
+	for _, r := range rs {
+		if !IsCrashed(r) {
+			as = append(as, r)
+		}
+	}
+	return as
+}
+
 func GetNotInFilter(rs []string) QuorumFilter {
 	return func(r string, _ []string) bool {
 		for _, s := range rs {
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// relative change of links used by sensitivity analysis
+const sensitivityStep = 0.1
+
+// LinkSensitivity is the change of some latency when the link between R1
+// and R2 is longer (Up) or shorter (Down) by sensitivityStep.
+type LinkSensitivity struct {
+	R1, R2   string
+	Up, Down float64
+}
+
+// Scales the link between `r1` and `r2` in both directions by `f` and
+// returns a function restoring it.
+func (t *LatencyTable) scaleLink(r1, r2 string, f float64) func() {
+	var restore []func()
+	for _, l := range []link{{r1, r2}, {r2, r1}} {
+		if old, exists := t.latency[l.r1][l.r2]; exists {
+			t.latency[l.r1][l.r2] = old * f
+			ll := l
+			restore = append(restore, func() {
+				t.latency[ll.r1][ll.r2] = old
+			})
+		}
+	}
+	return func() {
+		for _, r := range restore {
+			r()
+		}
+	}
+}
+
+// Change of `f` from `base` when the link between `r1` and `r2` gets longer
+// or shorter.
+func (t *LatencyTable) linkSensitivity(r1, r2 string, base float64, f func() float64) *LinkSensitivity {
+	s := &LinkSensitivity{R1: r1, R2: r2}
+	restore := t.scaleLink(r1, r2, 1+sensitivityStep)
+	s.Up = Round(f() - base)
+	restore()
+	restore = t.scaleLink(r1, r2, 1-sensitivityStep)
+	s.Down = Round(f() - base)
+	restore()
+	return s
+}
+
+// Computes how `f` changes when every link between regions `rs` gets longer
+// or shorter, ignoring links that do not change it, and sorts links by
+// decreasing impact.
+func (t *LatencyTable) sensitivity(rs []string, f func() float64) []*LinkSensitivity {
+	var ls []*LinkSensitivity
+	base := f()
+	for i, r1 := range rs {
+		for _, r2 := range rs[i+1:] {
+			if !t.HasLink(r1, r2) && !t.HasLink(r2, r1) {
+				continue
+			}
+			if s := t.linkSensitivity(r1, r2, base, f); s.Up != 0 || s.Down != 0 {
+				ls = append(ls, s)
+			}
+		}
+	}
+	sort.SliceStable(ls, func(i, j int) bool {
+		return math.Max(math.Abs(ls[i].Up), math.Abs(ls[i].Down)) >
+			math.Max(math.Abs(ls[j].Up), math.Abs(ls[j].Down))
+	})
+	return ls
+}
+
+func union(rs, cs []string) []string {
+	us := append([]string{}, rs...)
+	for _, c := range cs {
+		if !contains(us, c) {
+			us = append(us, c)
+		}
+	}
+	return us
+}
+
+// Hops of flow `hs` on the chain of messages from the request of `client`
+// to the reply after which it learns the outcome. Each hop is preceded by
+// the last one received by its sender before sending it, messages of
+// replicas to themselves being ignored unless they are critical.
+func criticalChain(hs []*Hop, client string) []*Hop {
+	end := flowEnd(hs, client)
+	var h *Hop
+	for _, r := range hs {
+		if r.To == client && r.Arrive == end && (h == nil || r.Critical) {
+			h = r
+		}
+	}
+	var chain []*Hop
+	for h != nil && len(chain) < len(hs) {
+		chain = append([]*Hop{h}, chain...)
+		var prev *Hop
+		for _, p := range hs {
+			if p == h || p.To != h.From || p.From == p.To && !p.Critical || p.Arrive > h.Send {
+				continue
+			}
+			if prev == nil || p.Arrive > prev.Arrive || p.Arrive == prev.Arrive && p.Critical {
+				prev = p
+			}
+		}
+		h = prev
+	}
+	return chain
+}
+
+// Links of the chain of messages that determines the latency of client `c`
+// with `alg` (see Flow), in the order of the chain, and how they change it.
+func CriticalPath(t *LatencyTable, alg Algorithm, c string, fast bool) []*LinkSensitivity {
+	var ls []*LinkSensitivity
+	base := alg.Accept(c, fast)
+	seen := map[link]struct{}{}
+	for _, h := range criticalChain(alg.Flow(c, fast), c) {
+		if _, exists := seen[link{h.From, h.To}]; exists || h.From == h.To {
+			continue
+		}
+		seen[link{h.From, h.To}], seen[link{h.To, h.From}] = struct{}{}, struct{}{}
+		ls = append(ls, t.linkSensitivity(h.From, h.To, base, func() float64 {
+			return alg.Accept(c, fast)
+		}))
+	}
+	return ls
+}
+
+// Links between replicas `rs` and clients `cs` ranked by how much they
+// change the average latency of `alg`.
+func Sensitivity(t *LatencyTable, alg Algorithm, rs, cs []string) []*LinkSensitivity {
+	return t.sensitivity(union(rs, cs), func() float64 {
+		return Average(alg, cs, true)
+	})
+}
+
+func (s *LinkSensitivity) describe(t *LatencyTable) string {
+	return fmt.Sprintf("%v <-> %v (%vms): %+0.3f / %+0.3f",
+		t.Site(s.R1), t.Site(s.R2), FormatMs(t.oneWay(s.R1, s.R2)), s.Up, s.Down)
+}
+
+// Describes the critical paths of client `c` with `alg` and ranks links
+// by their impact on the average latency of clients `cs`.
+func SensitivityReport(t *LatencyTable, alg Algorithm, twoPaths bool, rs, cs []string, c string) string {
+	s := fmt.Sprintf("%v with %v: %v\n", t.Site(c), alg, FormatLatency(alg.Accept(c, true)))
+	s += fmt.Sprintf("links (one-way): change for %+.0f%% / %+.0f%%\n", 100*sensitivityStep, -100*sensitivityStep)
+	paths := []bool{true}
+	if twoPaths {
+		paths = append(paths, false)
+	}
+	for _, fast := range paths {
+		s += "\ncritical path"
+		if twoPaths && fast {
+			s += " (fast)"
+		} else if twoPaths {
+			s += " (slow)"
+		}
+		s += ":\n"
+		for _, l := range CriticalPath(t, alg, c, fast) {
+			s += "  " + l.describe(t) + "\n"
+		}
+	}
+	s += "\naverage latency:\n"
+	for _, l := range Sensitivity(t, alg, rs, cs) {
+		s += "  " + l.describe(t) + "\n"
+	}
+	return s
+}
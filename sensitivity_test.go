@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCriticalChain(t *testing.T) {
+	lt, rs, cs := lineTable(t)
+	for _, p := range Protocols(lt, rs, cs) {
+		for _, c := range cs {
+			for _, fast := range []bool{true, false} {
+				hs := p.Alg.Flow(c, fast)
+				chain := criticalChain(hs, c)
+				if len(chain) == 0 {
+					t.Errorf("%v: no critical chain for %v", p.Name, c)
+					continue
+				}
+				if chain[0].From != c || chain[len(chain)-1].To != c {
+					t.Errorf("%v: chain of %v goes from %v to %v", p.Name, c, chain[0].From, chain[len(chain)-1].To)
+				}
+				for i := 1; i < len(chain); i++ {
+					if chain[i].From != chain[i-1].To || chain[i].Send < chain[i-1].Arrive {
+						t.Errorf("%v: %v -> %v does not follow %v -> %v for %v",
+							p.Name, chain[i].From, chain[i].To, chain[i-1].From, chain[i-1].To, c)
+					}
+				}
+				if end := chain[len(chain)-1].Arrive; end != flowEnd(hs, c) {
+					t.Errorf("%v: chain of %v ends at %v, flow at %v", p.Name, c, end, flowEnd(hs, c))
+				}
+			}
+		}
+	}
+}
+
+func TestPaxosCriticalChain(t *testing.T) {
+	lt, rs, _ := lineTable(t)
+	p := NewPaxos(rs, lt, false)
+	p.leader = "b"
+
+	// b waits for the farthest replica of its closest majority, c
+	chain := criticalChain(p.Flow("a", false), "a")
+	if len(chain) == 0 {
+		t.Fatal("no critical chain for a")
+	}
+	route := []string{"a"}
+	for _, h := range chain {
+		route = append(route, h.To)
+	}
+	if r := strings.Join(route, " "); r != "a b c b a" || chain[len(chain)-1].Arrive != 25 {
+		t.Errorf("critical chain of a is %v and ends at %v, want a b c b a ending at 25", r, chain[len(chain)-1].Arrive)
+	}
+}
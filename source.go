@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"strings"
+)
+
+// LatencySource is anything a latency table can be loaded from.
+type LatencySource interface {
+	String() string
+	Load() (*LatencyTable, error)
+}
+
+// Parses a URI-like latency source description:
+//
+//	cloudping:[percentile[/timeframe]]
+//	file:path (or just path)
+//	csv:path
+//	tsv:path
+//	json:path
+//	builtin:name
+//
+// Unknown schemes are rejected, unless they look like a path (including
+// Windows drive letters such as `C:`).
+func ParseLatencySource(uri string) (LatencySource, error) {
+	scheme, rest, found := strings.Cut(uri, ":")
+	if !found || len(scheme) == 1 || strings.ContainsAny(scheme, "/\\.") {
+		return &FileSource{Path: uri}, nil
+	}
+	switch scheme {
+	case "cloudping":
+		p, tf, _ := strings.Cut(rest, "/")
+		if _, err := CloudpingURL(p, tf); err != nil {
+			return nil, err
+		}
+		return &CloudpingSource{Percentile: p, Timeframe: tf}, nil
+	case "file":
+		return &FileSource{Path: rest}, nil
+	case "csv":
+		if strings.HasSuffix(rest, ".tsv") {
+			return &CSVSource{Path: rest, Comma: '\t'}, nil
+		}
+		return &CSVSource{Path: rest}, nil
+	case "tsv":
+		return &CSVSource{Path: rest, Comma: '\t'}, nil
+	case "json":
+		return &JSONSource{Path: rest}, nil
+	case "builtin":
+		return &BuiltinSource{Name: rest}, nil
+	}
+	return nil, errors.New("unknown latency source " + scheme + ": in " + uri)
+}
+
+type CloudpingSource struct {
+	Percentile string
+	Timeframe  string
+	Mode       CacheMode
+}
+
+// Sets the percentile and the timeframe of `s` if it does not specify
+// them, and fails if it specifies different ones.
+func (s *CloudpingSource) SetDefaults(percentile, timeframe string) error {
+	same := func(v1, v2 string) bool {
+		return strings.EqualFold(strings.ReplaceAll(v1, "_", ""), strings.ReplaceAll(v2, "_", ""))
+	}
+	if s.Percentile == "" {
+		s.Percentile = percentile
+	} else if percentile != "" && !same(s.Percentile, percentile) {
+		return errors.New(s.String() + " conflicts with percentile " + percentile)
+	}
+	if s.Timeframe == "" {
+		s.Timeframe = timeframe
+	} else if timeframe != "" && !same(s.Timeframe, timeframe) {
+		return errors.New(s.String() + " conflicts with timeframe " + timeframe)
+	}
+	_, err := CloudpingURL(s.Percentile, s.Timeframe)
+	return err
+}
+
+func (s *CloudpingSource) Load() (*LatencyTable, error) {
+	return NewLatencyTable(s.Percentile, s.Timeframe, s.Mode)
+}
+
+func (s *CloudpingSource) String() string {
+	if s.Timeframe != "" {
+		return "cloudping:" + s.Percentile + "/" + s.Timeframe
+	}
+	return "cloudping:" + s.Percentile
+}
+
+// FileSource reads latency tables in flint's `src dst latency` format.
+type FileSource struct {
+	Path string
+}
+
+func (s *FileSource) Load() (*LatencyTable, error) {
+	return NewLatencyTableFromFile(s.Path)
+}
+
+func (s *FileSource) String() string {
+	return "file:" + s.Path
+}
+
+// CSVSource reads square latency matrices whose first row and first column
+// list region names and whose cells contain round-trip latencies in ms.
+// Empty cells are missing links. Cells are separated by commas, or by tabs
+// if `Comma` is set to '\t'.
+type CSVSource struct {
+	Path  string
+	Comma rune
+}
+
+func (s *CSVSource) Load() (*LatencyTable, error) {
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	comma := s.Comma
+	if comma == 0 {
+		comma = ','
+	}
+	t, err := ReadMatrix(f, comma)
+	if err != nil {
+		return nil, errors.New(s.Path + ": " + err.Error())
+	}
+	return t, nil
+}
+
+func (s *CSVSource) String() string {
+	if s.Comma == '\t' {
+		return "tsv:" + s.Path
+	}
+	return "csv:" + s.Path
+}
+
+type jsonTable struct {
+	Regions []string                      `json:"regions"`
+	Latency map[string]map[string]float64 `json:"latency"`
+	// links that were not measured
+	Estimated [][2]string `json:"estimated,omitempty"`
+}
+
+// Returns the latencies between `rs`, with regions named by `name`.
+func (t *LatencyTable) jsonTable(rs []string, name func(string) string) jsonTable {
+	jt := jsonTable{Latency: map[string]map[string]float64{}}
+	for _, r1 := range rs {
+		jt.Regions = append(jt.Regions, name(r1))
+		ls := map[string]float64{}
+		for _, r2 := range rs {
+			if l, exists := t.latency[r1][r2]; exists {
+				ls[name(r2)] = l
+			}
+			if t.IsEstimated(r1, r2) {
+				jt.Estimated = append(jt.Estimated, [2]string{name(r1), name(r2)})
+			}
+		}
+		jt.Latency[name(r1)] = ls
+	}
+	return jt
+}
+
+// JSONSource reads latency tables of the form
+//
+//	{"regions": ["a", "b"], "latency": {"a": {"b": 12.5}}}
+//
+// with round-trip latencies in ms. If "regions" is omitted, the keys of
+// "latency" are used in lexicographic order. Links that were not measured
+// can be listed as `"estimated": [["a", "b"]]`.
+type JSONSource struct {
+	Path string
+}
+
+func (s *JSONSource) Load() (*LatencyTable, error) {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	var jt jsonTable
+	if err := json.Unmarshal(data, &jt); err != nil {
+		return nil, err
+	}
+	return jt.table(s.Path)
+}
+
+func (s *JSONSource) String() string {
+	return "json:" + s.Path
+}
+
+func (jt *jsonTable) table(name string) (*LatencyTable, error) {
+	if len(jt.Regions) == 0 {
+		for r := range jt.Latency {
+			jt.Regions = append(jt.Regions, r)
+		}
+		sort.Strings(jt.Regions)
+	}
+	if len(jt.Regions) == 0 {
+		return nil, errors.New(name + ": empty latency table")
+	}
+	for i, r := range jt.Regions {
+		jt.Regions[i] = NormalizeRegion(r)
+	}
+
+	t := newLatencyTable()
+	for _, r := range jt.Regions {
+		if _, exists := t.latency[r]; !exists {
+			t.addRegion(r)
+		}
+	}
+	for r1, ls := range jt.Latency {
+		r1 = NormalizeRegion(r1)
+		if _, exists := t.latency[r1]; !exists {
+			t.addRegion(r1)
+		}
+		for r2, l := range ls {
+			t.latency[r1][NormalizeRegion(r2)] = l
+		}
+	}
+	for _, e := range jt.Estimated {
+		t.estimated[link{NormalizeRegion(e[0]), NormalizeRegion(e[1])}] = struct{}{}
+	}
+	return t, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJSONEstimatedRoundTrip(t *testing.T) {
+	t1, err := ReadMatrix(strings.NewReader(matrix), ',')
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(t.TempDir(), "table.json")
+	if err := t1.Export(t1.regions, filename); err != nil {
+		t.Fatal(err)
+	}
+	t2, err := (&JSONSource{Path: filename}).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(t1.latency, t2.latency) || !reflect.DeepEqual(t1.estimated, t2.estimated) {
+		t.Errorf("JSON round trip changed the table: %v %v, want %v %v", t2.latency, t2.estimated, t1.latency, t1.estimated)
+	}
+}
+
+func TestCacheKeepsEstimated(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t1, err := ReadMatrix(strings.NewReader(matrix), ',')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveCachedTable("https://example.com/grid", t1); err != nil {
+		t.Fatal(err)
+	}
+	t2, err := LoadCachedTable("https://example.com/grid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(t1.estimated, t2.estimated) {
+		t.Errorf("cache lost estimated links: %v, want %v", t2.estimated, t1.estimated)
+	}
+}
+
+func TestParseLatencySource(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want LatencySource
+	}{
+		{"latency.txt", &FileSource{Path: "latency.txt"}},
+		{"file:latency.txt", &FileSource{Path: "latency.txt"}},
+		{`C:\data\lat.txt`, &FileSource{Path: `C:\data\lat.txt`}},
+		{"./a:b.txt", &FileSource{Path: "./a:b.txt"}},
+		{"csv:lat.tsv", &CSVSource{Path: "lat.tsv", Comma: '\t'}},
+		{"cloudping:p90/1W", &CloudpingSource{Percentile: "p90", Timeframe: "1W"}},
+		{"builtin:aws", &BuiltinSource{Name: "aws"}},
+	}
+	for _, test := range tests {
+		src, err := ParseLatencySource(test.uri)
+		if err != nil || !reflect.DeepEqual(src, test.want) {
+			t.Errorf("ParseLatencySource(%q) = %#v, %v, want %#v", test.uri, src, err, test.want)
+		}
+	}
+	for _, uri := range []string{"http://example.com/lat.txt", "cloudping:p42"} {
+		if _, err := ParseLatencySource(uri); err == nil {
+			t.Errorf("parsed %q", uri)
+		}
+	}
+}
@@ -26,17 +26,26 @@ func (s *SwiftPaxos) GetReplicas() []string {
 	return s.rs
 }
 
+// The fast path is unavailable if a member of the fixed fast quorum is
+// crashed or unreachable, the slow path if there is no alive and reachable
+// leader or no alive and reachable majority.
 func (s *SwiftPaxos) Accept(client string, fast bool) float64 {
+	if s.leader == "" || IsCrashed(s.leader) || !Reachable(client, s.leader) {
+		return math.Inf(1)
+	}
 	m := 0.0
 	if fast {
 		for r := range s.fastQ {
+			if IsCrashed(r) || !Reachable(client, r) {
+				return s.Accept(client, false)
+			}
 			l := s.Propagate(client, r) + s.FastAck(r, client)
 			m = math.Max(m, l)
 		}
 		return math.Min(m, s.Accept(client, false))
 	}
 	m = math.Inf(1)
-	slowQs := QuorumsOfSize(len(s.rs)/2+1, s.rs, NoFilter)
+	slowQs := QuorumsOfSize(len(s.rs)/2+1, s.rs, ReachableFilter(client, s.leader))
 	for _, q := range slowQs {
 		qm := 0.0
 		for r := range q {
@@ -48,18 +57,97 @@ func (s *SwiftPaxos) Accept(client string, fast bool) float64 {
 	return m
 }
 
+// The client sends the command to every replica and the leader sends its
+// ordering to replicas. Fast quorum members reply on the fast path, every
+// replica on the slow path.
+func (s *SwiftPaxos) Messages(client string, fast bool) []Message {
+	if s.leader == "" || IsCrashed(s.leader) {
+		return nil
+	}
+	rs := Alive(s.rs)
+	ms := send(nil, client, rs, CommandSize)
+	ms = send(ms, s.leader, rs, AckSize)
+	if fast {
+		for r := range s.fastQ {
+			if !IsCrashed(r) {
+				ms = send(ms, r, []string{client}, AckSize)
+			}
+		}
+		return ms
+	}
+	for _, r := range rs {
+		ms = send(ms, r, []string{client}, AckSize)
+	}
+	return ms
+}
+
+// The fast path is drawn unless the slow path is faster.
+func (s *SwiftPaxos) Flow(client string, fast bool) []*Hop {
+	if s.leader == "" || IsCrashed(s.leader) {
+		return nil
+	}
+	reqs := map[string]*Hop{}
+	var hs []*Hop
+	for _, r := range s.rs {
+		if ReachableFilter(client)(r, nil) {
+			reqs[r] = hop(s.latency, client, r, "request", 0)
+			hs = append(hs, reqs[r])
+		}
+	}
+	if reqs[s.leader] == nil {
+		return nil
+	}
+
+	var fastAcks []*Hop
+	for r := range s.fastQ {
+		if reqs[r] == nil {
+			fastAcks = nil
+			break
+		}
+		fastAcks = append(fastAcks, hop(s.latency, r, client, "fast ack", reqs[r].Arrive+PersistDelay(r)))
+	}
+
+	slow := append([]*Hop{}, hs...)
+	var slowAcks []*Hop
+	order := reqs[s.leader].Arrive + BatchWait() + PersistDelay(s.leader)
+	for _, r := range s.rs {
+		if reqs[r] == nil || !Reachable(s.leader, r) {
+			continue
+		}
+		o := hop(s.latency, s.leader, r, "order", order)
+		if r != s.leader {
+			slow = append(slow, o)
+		}
+		slowAcks = append(slowAcks, hop(s.latency, r, client, "slow ack", math.Max(reqs[r].Arrive, o.Arrive)+PersistDelay(r)))
+	}
+	if quorumLast(slowAcks, len(s.rs)/2+1) != nil {
+		slow = append(slow, slowAcks...)
+	} else {
+		slow = nil
+	}
+
+	if fast && fastAcks != nil {
+		if last := quorumLast(fastAcks, len(fastAcks)); slow == nil || last.Arrive <= flowEnd(slow, client) {
+			return append(hs, fastAcks...)
+		}
+	}
+	return slow
+}
+
 func (s *SwiftPaxos) Propagate(client, replica string) float64 {
 	return s.latency.OneWayLatency(client, replica)
 }
 
+// Replicas persist the command (and the leader its ordering, once the batch
+// of the command is closed) before acking.
 func (s *SwiftPaxos) FastAck(replica, to string) float64 {
-	return s.latency.OneWayLatency(replica, to)
+	return PersistDelay(replica) + s.latency.OneWayLatency(replica, to)
 }
 
 func (s *SwiftPaxos) SlowAck(client, replica, to string) float64 {
 	l1 := s.Propagate(client, replica)
-	l2 := s.Propagate(client, s.leader) + s.FastAck(s.leader, replica)
-	return math.Max(l1, l2) + s.latency.OneWayLatency(replica, to)
+	l2 := s.Propagate(client, s.leader) + BatchWait() + s.FastAck(s.leader, replica)
+	return math.Max(l1, l2) + PersistDelay(replica) + s.latency.OneWayLatency(replica, to)
 }
 
 func (s *SwiftPaxos) SetAverageBestLeader(cs []string) (string, float64) {
@@ -67,22 +155,25 @@ func (s *SwiftPaxos) SetAverageBestLeader(cs []string) (string, float64) {
 	leader := ""
 
 	for r := range s.fastQ {
+		if IsCrashed(r) {
+			continue
+		}
 		s.leader = r
 		if MinWorstLatency {
-			l := Average(s, cs, false)
+			l := Cost(s, cs, false)
 			if l < min {
 				min = l
 				leader = r
 			} else if l == min {
-				l1 := Average(s, cs, true)
+				l1 := Cost(s, cs, true)
 				s.leader = leader
-				l2 := Average(s, cs, true)
+				l2 := Cost(s, cs, true)
 				if l1 < l2 {
 					leader = r
 				}
 			}
 		} else {
-			l := Average(s, cs, true)
+			l := Cost(s, cs, true)
 			if l < min {
 				min = l
 				leader = r
@@ -109,10 +200,10 @@ func (s *SwiftPaxos) SetAverageBestFixedQuorumAndLeader(cs []string, f QuorumFil
 			fastQ = q
 			leader = l
 		} else if m == min {
-			l1 := Average(s, cs, true)
+			l1 := Cost(s, cs, true)
 			s.fastQ = fastQ
 			s.leader = leader
-			l2 := Average(s, cs, true)
+			l2 := Cost(s, cs, true)
 			if l1 < l2 {
 				leader = l
 				fastQ = q
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Row of a comparison table: the latency of a client with some protocol and
+// its speedup (%) over other protocols, negative if it is slower.
+type comparisonRow struct {
+	client   string
+	slow     bool
+	latency  float64
+	speedups []float64
+}
+
+func speedup(l, other float64) float64 {
+	if l <= other {
+		return Faster(other, l)
+	}
+	return -Faster(l, other)
+}
+
+// Compares protocol `name` of `ps` with the other ones for clients `cs`.
+// `rows` is "fast", "slow" or "both" (slow rows are then only added for
+// protocols with two paths).
+func comparison(ps []*Protocol, name string, cs []string, rows string) (*Protocol, []*Protocol, []*comparisonRow, error) {
+	var (
+		p      *Protocol
+		others []*Protocol
+	)
+	for _, q := range ps {
+		if q.Name == name {
+			p = q
+		} else {
+			others = append(others, q)
+		}
+	}
+	if p == nil {
+		return nil, nil, nil, errors.New("unknown protocol " + name)
+	}
+	var paths []bool
+	switch rows {
+	case "fast":
+		paths = []bool{true}
+	case "slow":
+		paths = []bool{false}
+	case "both":
+		paths = []bool{true}
+		if p.TwoPaths {
+			paths = append(paths, false)
+		}
+	default:
+		return nil, nil, nil, errors.New("rows must be fast, slow or both, not " + rows)
+	}
+
+	sorted := append([]string{}, cs...)
+	sort.Strings(sorted)
+	var table []*comparisonRow
+	for _, c := range sorted {
+		for _, fast := range paths {
+			r := &comparisonRow{
+				client:  c,
+				slow:    !fast,
+				latency: Average(p.Alg, []string{c}, fast),
+			}
+			for _, o := range others {
+				r.speedups = append(r.speedups, speedup(r.latency, Average(o.Alg, []string{c}, fast)))
+			}
+			table = append(table, r)
+		}
+	}
+	return p, others, table, nil
+}
+
+func (r *comparisonRow) label(t *LatencyTable, rows string, sites bool) string {
+	l := r.client
+	if sites {
+		l = t.Site(r.client)
+	}
+	if r.slow && rows == "both" {
+		l += " (slow)"
+	}
+	return l
+}
+
+func latencyHeader(p *Protocol, rows string) string {
+	if rows == "slow" {
+		return p.Name + " slow path (ms)"
+	}
+	return p.Name + " (ms)"
+}
+
+var texEscaper = strings.NewReplacer(`\`, `\textbackslash{}`, "&", `\&`, "%", `\%`, "$", `\$`,
+	"#", `\#`, "_", `\_`, "{", `\{`, "}", `\}`, "~", `\textasciitilde{}`, "^", `\textasciicircum{}`,
+	"|", `\textbar{}`, "²", `\textsuperscript{2}`)
+
+// Comparison of protocol `name` with the other protocols of `ps` for
+// clients `cs` as a LaTeX booktabs table. Speedups are green and slowdowns
+// red (requires the booktabs and xcolor packages). Clients are named by
+// their sites if `sites` is set.
+func ComparisonLaTeX(t *LatencyTable, ps []*Protocol, name string, cs []string, rows string, sites bool) (string, error) {
+	p, others, table, err := comparison(ps, name, cs, rows)
+	if err != nil {
+		return "", err
+	}
+	s := "% \\usepackage{booktabs,xcolor}\n"
+	s += "\\begin{tabular}{l" + strings.Repeat("r", len(others)+1) + "}\n\\toprule\n"
+	s += "Client & " + texEscaper.Replace(latencyHeader(p, rows))
+	for _, o := range others {
+		s += " & vs " + texEscaper.Replace(o.Name)
+	}
+	s += " \\\\\n\\midrule\n"
+	for _, r := range table {
+		s += texEscaper.Replace(r.label(t, rows, sites)) + " & "
+		if math.IsInf(r.latency, 1) {
+			s += "$\\infty$"
+		} else {
+			s += fmt.Sprintf("%0.3f", r.latency)
+		}
+		for _, f := range r.speedups {
+			if f >= 0 {
+				s += fmt.Sprintf(" & \\textcolor{green!60!black}{+%.0f\\%%}", f)
+			} else {
+				s += fmt.Sprintf(" & \\textcolor{red}{%.0f\\%%}", f)
+			}
+		}
+		s += " \\\\\n"
+	}
+	return s + "\\bottomrule\n\\end{tabular}\n", nil
+}
+
+var markdownEscaper = strings.NewReplacer("|", `\|`)
+
+// Comparison of protocol `name` with the other protocols of `ps` for
+// clients `cs` as a GitHub Markdown table, with signed speedups.
+func ComparisonMarkdown(t *LatencyTable, ps []*Protocol, name string, cs []string, rows string, sites bool) (string, error) {
+	p, others, table, err := comparison(ps, name, cs, rows)
+	if err != nil {
+		return "", err
+	}
+	s := "| Client | " + markdownEscaper.Replace(latencyHeader(p, rows)) + " |"
+	for _, o := range others {
+		s += " vs " + markdownEscaper.Replace(o.Name) + " |"
+	}
+	s += "\n|:--|--:|" + strings.Repeat("--:|", len(others)) + "\n"
+	for _, r := range table {
+		s += "| " + markdownEscaper.Replace(r.label(t, rows, sites)) + " | " + strings.TrimSpace(FormatLatency(r.latency)) + " |"
+		for _, f := range r.speedups {
+			s += fmt.Sprintf(" %+.0f%% |", f)
+		}
+		s += "\n"
+	}
+	return s, nil
+}
+
+// Exports the comparison of protocol `name` with the other protocols over
+// replicas `rs` for clients `cs` into `filename`: ".tex" files contain a
+// LaTeX table and anything else a Markdown one.
+func ExportComparison(t *LatencyTable, rs, cs []string, name, rows string, sites bool, filename string) error {
+	var (
+		s   string
+		err error
+	)
+	ps := Protocols(t, rs, cs)
+	if strings.ToLower(filepath.Ext(filename)) == ".tex" {
+		s, err = ComparisonLaTeX(t, ps, name, cs, rows, sites)
+	} else {
+		s, err = ComparisonMarkdown(t, ps, name, cs, rows, sites)
+	}
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, []byte(s), 0644)
+}
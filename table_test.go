@@ -0,0 +1,92 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// algorithm with fixed client latencies on each path
+type twoPaths struct {
+	fixed
+	slow fixed
+}
+
+func (p twoPaths) Accept(c string, fast bool) float64 {
+	if fast {
+		return p.fixed[c]
+	}
+	return p.slow[c]
+}
+
+func comparisonProtocols() []*Protocol {
+	return []*Protocol{
+		{
+			Name:     "N²Paxos",
+			Alg:      twoPaths{fixed{"a_1": 10, "b|2": math.Inf(1)}, fixed{"a_1": 20, "b|2": math.Inf(1)}},
+			TwoPaths: true,
+		},
+		{Name: "Paxos_2|x", Alg: fixed{"a_1": 20, "b|2": 40}},
+	}
+}
+
+func TestComparisonMarkdown(t *testing.T) {
+	cs := []string{"b|2", "a_1"}
+	s, err := ComparisonMarkdown(nil, comparisonProtocols(), "N²Paxos", cs, "both", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `| Client | N²Paxos (ms) | vs Paxos_2\|x |
+|:--|--:|--:|
+| a_1 | 10.000 | +50% |
+| a_1 (slow) | 20.000 | +0% |
+| b\|2 | ∞ | -100% |
+| b\|2 (slow) | ∞ | -100% |
+`
+	if s != want {
+		t.Errorf("wrote\n%v\nwant\n%v", s, want)
+	}
+
+	// slow rows are only added for protocols with two paths
+	s, err = ComparisonMarkdown(nil, comparisonProtocols(), "Paxos_2|x", cs, "both", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = `| Client | Paxos_2\|x (ms) | vs N²Paxos |
+|:--|--:|--:|
+| a_1 | 20.000 | -50% |
+| b\|2 | 40.000 | +100% |
+`
+	if s != want {
+		t.Errorf("wrote\n%v\nwant\n%v", s, want)
+	}
+}
+
+func TestComparisonLaTeX(t *testing.T) {
+	s, err := ComparisonLaTeX(nil, comparisonProtocols(), "N²Paxos", []string{"b|2", "a_1"}, "slow", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `% \usepackage{booktabs,xcolor}
+\begin{tabular}{lrr}
+\toprule
+Client & N\textsuperscript{2}Paxos slow path (ms) & vs Paxos\_2\textbar{}x \\
+\midrule
+a\_1 & 20.000 & \textcolor{green!60!black}{+0\%} \\
+b\textbar{}2 & $\infty$ & \textcolor{red}{-100\%} \\
+\bottomrule
+\end{tabular}
+`
+	if s != want {
+		t.Errorf("wrote\n%v\nwant\n%v", s, want)
+	}
+}
+
+func TestComparisonErrors(t *testing.T) {
+	ps := comparisonProtocols()
+	if _, err := ComparisonMarkdown(nil, ps, "Raft", []string{"a_1"}, "fast", false); err == nil {
+		t.Error("no error comparing an unknown protocol")
+	}
+	if _, err := ComparisonLaTeX(nil, ps, "N²Paxos", []string{"a_1"}, "medium", false); err == nil {
+		t.Error("no error with medium rows")
+	}
+}
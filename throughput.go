@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	// CPU time (µs) a replica spends sending or receiving a message
+	DefaultMessageCost = 10.0
+	// NIC bandwidth (Gbps) of a replica in each direction
+	DefaultBandwidth = 10.0
+
+	MessageCosts = map[string]float64{}
+	Bandwidths   = map[string]float64{}
+
+	// loads (fractions of the maximum throughput) of latency-vs-load curves
+	loadLevels = []float64{0, 0.5, 0.8, 0.9, 0.95, 0.99}
+)
+
+func MessageCost(r string) float64 {
+	if c, exists := MessageCosts[r]; exists {
+		return c
+	}
+	return DefaultMessageCost
+}
+
+func Bandwidth(r string) float64 {
+	if b, exists := Bandwidths[r]; exists {
+		return b
+	}
+	return DefaultBandwidth
+}
+
+// Loads capacities of replicas from a file with lines
+// `region message-cost(µs) bandwidth(Gbps)`.
+func (t *LatencyTable) LoadCapacities(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		data := strings.Fields(s.Text())
+		if len(data) != 3 || strings.HasPrefix(data[0], "#") {
+			continue
+		}
+		r, exists := t.Region(data[0])
+		if !exists {
+			return errors.New(filename + ": unknown region " + data[0])
+		}
+		c, err := strconv.ParseFloat(data[1], 64)
+		if err != nil {
+			return errors.New(filename + ": " + err.Error())
+		}
+		b, err := strconv.ParseFloat(data[2], 64)
+		if err != nil {
+			return errors.New(filename + ": " + err.Error())
+		}
+		if c < 0 || b <= 0 {
+			return errors.New(filename + ": invalid capacity of " + data[0])
+		}
+		MessageCosts[r], Bandwidths[r] = c, b
+	}
+	return s.Err()
+}
+
+// Service time (ms) of a command of `alg` at each replica of `rs`, averaged
+// over clients `cs` weighted by their request rates. A replica is busy for
+// the longest of the CPU time spent on the messages it sends and receives
+// and of the time its NIC needs to send or to receive their bytes.
+// Clients co-located with a replica are accounted as that replica.
+func ServiceTimes(alg Algorithm, rs, cs []string, fast bool) map[string]float64 {
+	cpu, out, in := map[string]float64{}, map[string]float64{}, map[string]float64{}
+	w := 0.0
+	for _, c := range cs {
+		w += Weight(c)
+	}
+	for _, c := range cs {
+		weight := Weight(c)
+		if w == 0 {
+			weight = 1
+		}
+		for _, m := range alg.Messages(c, fast) {
+			if contains(rs, m.From) {
+				cpu[m.From] += weight * MessageCost(m.From)
+				out[m.From] += weight * float64(m.Size)
+			}
+			if contains(rs, m.To) {
+				cpu[m.To] += weight * MessageCost(m.To)
+				in[m.To] += weight * float64(m.Size)
+			}
+		}
+	}
+	if w == 0 {
+		w = float64(len(cs))
+	}
+
+	ss := map[string]float64{}
+	for _, r := range Alive(rs) {
+		// 1 Gbps is 1e6 bits per ms
+		nic := 8 * math.Max(out[r], in[r]) / (Bandwidth(r) * 1e6)
+		ss[r] = math.Max(cpu[r]/1000, nic) / w
+	}
+	return ss
+}
+
+// Maximum throughput (commands per second) of `alg` and the replica that
+// saturates first.
+func MaxThroughput(alg Algorithm, rs, cs []string) (float64, string) {
+	max, bottleneck := 0.0, ""
+	for r, s := range ServiceTimes(alg, rs, cs, true) {
+		if s > max || s == max && r < bottleneck {
+			max, bottleneck = s, r
+		}
+	}
+	if max == 0 {
+		return math.Inf(1), bottleneck
+	}
+	return 1000 / max, bottleneck
+}
+
+// Average latency of `alg` under `load` commands per second. Each replica
+// is approximated by an M/M/1 queue, and commands are delayed by the
+// sojourn time at the most loaded one, which every quorum is assumed to
+// wait for. Returns +Inf if some replica is saturated.
+func LatencyUnderLoad(alg Algorithm, rs, cs []string, load float64) float64 {
+	l := Average(alg, cs, true)
+	wait := 0.0
+	for _, s := range ServiceTimes(alg, rs, cs, true) {
+		rho := load * s / 1000
+		if rho >= 1 {
+			return math.Inf(1)
+		}
+		wait = math.Max(wait, s/(1-rho))
+	}
+	return Round(l + wait)
+}
+
+func ThroughputReport(t *LatencyTable, rs, cs []string) string {
+	s := fmt.Sprintf("%-15v %11v  %-15v", "", "max ops/s", "bottleneck")
+	for _, f := range loadLevels {
+		s += fmt.Sprintf(" %7.0f%%", 100*f)
+	}
+	s += "\n"
+	for _, p := range Protocols(t, rs, cs) {
+		max, bottleneck := MaxThroughput(p.Alg, rs, cs)
+		if bottleneck == "" {
+			bottleneck = "none"
+		} else {
+			bottleneck = t.IdOf(bottleneck)
+		}
+		s += fmt.Sprintf("%-15v %11.0f  %-15v", p.Name, max, bottleneck)
+		for _, f := range loadLevels {
+			s += " " + FormatLatency(LatencyUnderLoad(p.Alg, rs, cs, f*max))
+		}
+		s += "\n"
+	}
+	return s
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// algorithm where the leader `a` forwards every command to `b`
+type relay struct {
+	fixed
+}
+
+func (r relay) Messages(c string, fast bool) []Message {
+	ms := send(nil, c, []string{"a"}, 1000)
+	ms = send(ms, "a", []string{"b"}, 1000)
+	ms = send(ms, "b", []string{"a"}, 100)
+	return send(ms, "a", []string{c}, 100)
+}
+
+func TestMaxThroughput(t *testing.T) {
+	defer func(c, b float64, cs, bs map[string]float64) {
+		DefaultMessageCost, DefaultBandwidth, MessageCosts, Bandwidths = c, b, cs, bs
+	}(DefaultMessageCost, DefaultBandwidth, MessageCosts, Bandwidths)
+	DefaultMessageCost, DefaultBandwidth = 10, 10
+	MessageCosts, Bandwidths = map[string]float64{}, map[string]float64{}
+
+	alg := relay{fixed{"c": 50}}
+	rs, cs := []string{"a", "b"}, []string{"c"}
+
+	// the leader handles 4 messages, i.e., 40µs of CPU per command
+	if max, r := MaxThroughput(alg, rs, cs); math.Abs(max-25000) > 1e-6 || r != "a" {
+		t.Errorf("CPU-bound throughput is %v with bottleneck %v, want 25000 with a", max, r)
+	}
+	// b receives 1000 bytes per command at 1 Mbps
+	Bandwidths["b"] = 0.001
+	if max, r := MaxThroughput(alg, rs, cs); math.Abs(max-125) > 1e-6 || r != "b" {
+		t.Errorf("NIC-bound throughput is %v with bottleneck %v, want 125 with b", max, r)
+	}
+	DefaultMessageCost, DefaultBandwidth, Bandwidths = 0, math.Inf(1), map[string]float64{}
+	if max, r := MaxThroughput(alg, rs, cs); !math.IsInf(max, 1) {
+		t.Errorf("free replicas have throughput %v with bottleneck %v", max, r)
+	}
+}
+
+func TestLatencyUnderLoad(t *testing.T) {
+	defer func(c, b float64, cs, bs map[string]float64) {
+		DefaultMessageCost, DefaultBandwidth, MessageCosts, Bandwidths = c, b, cs, bs
+	}(DefaultMessageCost, DefaultBandwidth, MessageCosts, Bandwidths)
+	DefaultMessageCost, DefaultBandwidth = 10, math.Inf(1)
+	MessageCosts, Bandwidths = map[string]float64{}, map[string]float64{}
+
+	alg := relay{fixed{"c": 50}}
+	rs, cs := []string{"a", "b"}, []string{"c"}
+	tests := []struct {
+		load, want float64
+	}{
+		// sojourn time 0.04ms of the leader
+		{0, 50.04},
+		// half loaded leader
+		{12500, 50.08},
+		{25000, math.Inf(1)},
+		{30000, math.Inf(1)},
+	}
+	for _, test := range tests {
+		if l := LatencyUnderLoad(alg, rs, cs, test.load); l != test.want {
+			t.Errorf("latency under %v ops/s is %v, want %v", test.load, l, test.want)
+		}
+	}
+}
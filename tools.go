@@ -1,9 +1,22 @@
 package main
 
-import "math"
+import (
+	"math"
+	"strconv"
+)
+
+var (
+	// number of decimal digits (of ms) kept by the rounding helpers,
+	// negative values disable rounding
+	Precision = 2
+)
 
 func Round(x float64) float64 {
-	return math.Round(x*100) / 100
+	if Precision < 0 || math.IsInf(x, 0) || math.IsNaN(x) {
+		return x
+	}
+	p := math.Pow10(Precision)
+	return math.Round(x*p) / p
 }
 
 func Mul(x, y float64) float64 {
@@ -13,3 +26,9 @@ func Mul(x, y float64) float64 {
 func Div(x, y float64) float64 {
 	return Round(x / y)
 }
+
+// Formats latency `l` (ms) with up to microsecond precision and without
+// trailing zeros.
+func FormatMs(l float64) string {
+	return strconv.FormatFloat(math.Round(l*1e3)/1e3, 'f', -1, 64)
+}
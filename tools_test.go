@@ -0,0 +1,61 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRound(t *testing.T) {
+	defer func(p int) {
+		Precision = p
+	}(Precision)
+
+	tests := []struct {
+		precision int
+		x, want   float64
+	}{
+		{2, 71.4849, 71.48},
+		{2, 71.485001, 71.49},
+		{0, 71.5, 72},
+		{3, 0.0004, 0},
+		{3, 0.0125, 0.013},
+		{-1, 71.4849, 71.4849},
+		{2, math.Inf(1), math.Inf(1)},
+	}
+	for _, test := range tests {
+		Precision = test.precision
+		if got := Round(test.x); got != test.want {
+			t.Errorf("Round(%v) with precision %d = %v, want %v", test.x, test.precision, got, test.want)
+		}
+	}
+
+	Precision = 2
+	if got := Mul(0.333, 3); got != 1 {
+		t.Errorf("Mul(0.333, 3) = %v, want 1", got)
+	}
+	if got := Div(1, 3); got != 0.33 {
+		t.Errorf("Div(1, 3) = %v, want 0.33", got)
+	}
+	if !math.IsNaN(Round(math.NaN())) {
+		t.Error("Round(NaN) is not NaN")
+	}
+}
+
+func TestFormatMs(t *testing.T) {
+	tests := []struct {
+		l    float64
+		want string
+	}{
+		{71, "71"},
+		{71.5, "71.5"},
+		{0.05, "0.05"},
+		{0.0125, "0.013"},
+		{0.0004, "0"},
+		{190.123456, "190.123"},
+	}
+	for _, test := range tests {
+		if got := FormatMs(test.l); got != test.want {
+			t.Errorf("FormatMs(%v) = %v, want %v", test.l, got, test.want)
+		}
+	}
+}
@@ -1,11 +1,13 @@
 package main
 
 import (
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
 	"math"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"unicode/utf8"
 
@@ -21,10 +23,21 @@ var (
 
 	pages *tview.Pages
 
-	quorumPr      *tview.TextView
-	leaderPr      *tview.TextView
-	latencyPr     *tview.TextView
-	clientsInfoPr *tview.TextView
+	quorumPr       *tview.TextView
+	leaderPr       *tview.TextView
+	latencyPr      *tview.TextView
+	availabilityPr *tview.TextView
+	costPr         *tview.TextView
+	clientsInfoPr  *tview.TextView
+	detailPr       *tview.TextView
+
+	// settings the text of availabilityPr was computed with
+	availabilityKey string
+	// clients of the rows of clientsInfoPr by region id
+	clientRows map[string]string
+	// client shown in detailPr and the protocol it is shown with
+	detailClient   string
+	detailProtocol *Protocol
 
 	selectedClients  []string
 	selectedReplicas []string
@@ -32,46 +45,88 @@ var (
 	application *tview.Application
 )
 
-func Regions(label string, t *LatencyTable, f func(rs map[string]struct{})) tview.Primitive {
-	form := tview.NewForm()
+// Site name of `r` marked with "~" if some of its links are estimated.
+func SiteLabel(t *LatencyTable, r string) string {
+	if t.HasEstimates(r) {
+		return t.Site(r) + " ~"
+	}
+	return t.Site(r)
+}
+
+// Client sites are listed only if `sites` is set.
+func Regions(label string, t *LatencyTable, sites bool, f func(rs map[string]struct{})) tview.Primitive {
 	s := map[string]struct{}{}
+	root := tview.NewTreeNode(label)
+	tree := tview.NewTreeView().SetRoot(root).SetTopLevel(1)
+	tree.SetGraphics(false)
 
-	for _, r := range t.regions {
-		c := tview.NewCheckbox()
-		c.SetLabel(t.Site(r))
-		c.SetChecked(false)
-		rr := r
-		c.SetChangedFunc(func(checked bool) {
-			r := rr
-			if checked {
-				s[r] = struct{}{}
-			} else if _, exists := s[r]; exists {
-				delete(s, r)
+	var gs []*RegionGroup
+	for _, g := range t.Groups() {
+		h := &RegionGroup{Name: g.Name}
+		for _, r := range g.Regions {
+			if sites || !t.IsSite(r) {
+				h.Regions = append(h.Regions, r)
+			}
+		}
+		if len(h.Regions) > 0 {
+			gs = append(gs, h)
+		}
+	}
+	for _, g := range gs {
+		parent := root
+		if len(gs) > 1 {
+			parent = tview.NewTreeNode("").SetColor(tcell.ColorWhite)
+			root.AddChild(parent)
+		}
+		gg, gn := g, parent
+		update := func() {
+			if len(gs) < 2 {
+				return
 			}
-			if f != nil {
-				f(s)
+			n := 0
+			for _, r := range gg.Regions {
+				if _, exists := s[r]; exists {
+					n++
+				}
 			}
+			arrow := "▾"
+			if !gn.IsExpanded() {
+				arrow = "▸"
+			}
+			gn.SetText(fmt.Sprintf("%v %v (%d/%d)", arrow, gg.Name, n, len(gg.Regions)))
+		}
+		update()
+		parent.SetSelectedFunc(func() {
+			gn.SetExpanded(!gn.IsExpanded())
+			update()
 		})
-		form.AddFormItem(c)
-	}
 
-	form.SetBorder(true).SetTitle(label).SetTitleAlign(tview.AlignLeft)
-	form.SetItemPadding(0)
-	form.ClearButtons()
-	form.SetLabelColor(tcell.ColorWhite)
-	form.SetFieldTextColor(tcell.ColorWhite)
-	form.SetFieldBackgroundColor(tcell.ColorGrey)
-	form.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch key := event.Key(); key {
-		case tcell.KeyDown:
-			return tcell.NewEventKey(tcell.KeyTab, 't', tcell.ModNone)
-		case tcell.KeyUp:
-			return tcell.NewEventKey(tcell.KeyBacktab, 't', tcell.ModNone)
+		for _, r := range g.Regions {
+			rr := r
+			c := tview.NewTreeNode(tview.Escape("[ ] ") + SiteLabel(t, r)).SetColor(tcell.ColorWhite)
+			c.SetSelectedFunc(func() {
+				r := rr
+				if _, exists := s[r]; !exists {
+					s[r] = struct{}{}
+					c.SetText(tview.Escape("[x] ") + SiteLabel(t, r))
+				} else {
+					delete(s, r)
+					c.SetText(tview.Escape("[ ] ") + SiteLabel(t, r))
+				}
+				update()
+				if f != nil {
+					f(s)
+				}
+			})
+			parent.AddChild(c)
 		}
-		return event
-	})
+	}
+	if len(root.GetChildren()) > 0 {
+		tree.SetCurrentNode(root.GetChildren()[0])
+	}
 
-	return form
+	tree.SetBorder(true).SetTitle(label).SetTitleAlign(tview.AlignLeft)
+	return tree
 }
 
 func Redraw(t *LatencyTable) {
@@ -88,36 +143,95 @@ func Redraw(t *LatencyTable) {
 		quorumPr.Clear()
 		leaderPr.Clear()
 		latencyPr.Clear()
+		availabilityPr.Clear()
+		availabilityKey = ""
+		costPr.Clear()
 		clientsInfoPr.Clear()
+		detailPr.Clear()
 		return
 	}
-
-	sp := NewSwiftPaxos(selectedReplicas, t)
-	c := NewCurpN2Paxos(selectedReplicas, t)
-	p := NewPaxos(selectedReplicas, t, false)
-	n := NewPaxos(selectedReplicas, t, true)
-	a := NewAccord(selectedReplicas, t)
-	quorumSp, leaderSp, _ := sp.SetAverageBestFixedQuorumAndLeader(selectedClients, NoFilter)
-	leaderC, _ := c.SetAverageBestLeader(selectedClients)
-	leaderP, _ := p.SetAverageBestLeader(selectedClients)
-	leaderN, _ := n.SetAverageBestLeader(selectedClients)
+	UpdateAvailability(t)
+	costPr.SetText(CostReport(t, selectedReplicas, selectedClients))
 
 	_, protocol := protocolPr.GetCurrentOption()
-	switch protocol {
-	case "Accord":
-		UpdateClientInfo("<leaderless>", nil, a, t, true, false, []Algorithm{sp, c, p, n})
-	case "SwiftPaxos":
-		UpdateClientInfo(leaderSp, quorumSp, sp, t, true, false, []Algorithm{c, p, n, a})
-	case "Paxos":
-		UpdateClientInfo(leaderP, nil, p, t, false, false, []Algorithm{sp, c, n, a})
-	case "N²Paxos":
-		UpdateClientInfo(leaderN, nil, n, t, false, true, []Algorithm{sp, c, p, a})
-	case "CURP (N²Paxos)":
-		UpdateClientInfo(leaderC, nil, c, t, true, true, []Algorithm{sp, p, n, a})
+	var baseline map[string]float64
+	failed := len(Partitioned) != 0
+	for _, r := range selectedReplicas {
+		failed = failed || IsCrashed(r)
+	}
+	if failed {
+		baseline = map[string]float64{}
+		WithCrashed(Quorum{}, func() {
+			WithPartition(Partition{}, func() {
+				for _, p := range Protocols(t, selectedReplicas, selectedClients) {
+					if p.Name == protocol {
+						for _, c := range selectedClients {
+							baseline[c] = Average(p.Alg, []string{c}, true)
+						}
+					}
+				}
+			})
+		})
+	}
+	ps := Protocols(t, selectedReplicas, selectedClients)
+	for _, p := range ps {
+		if p.Name == protocol {
+			UpdateClientInfo(p.Leader, p.Quorum, p.Alg, t, p.TwoPaths, p.Closest, Others(ps, p.Name), baseline)
+			detailProtocol = p
+			OutdateDetail(t)
+		}
+	}
+}
+
+// Region id of the row of client `c` in clientsInfoPr, which only allows
+// alphanumeric region ids.
+func clientRow(c string) string {
+	return "client-" + hex.EncodeToString([]byte(c))
+}
+
+// Availabilities enumerate every failure state, so the report is only
+// recomputed when the selection or a setting it depends on changes.
+func UpdateAvailability(t *LatencyTable) {
+	rs := append([]string{}, selectedReplicas...)
+	cs := append([]string{}, selectedClients...)
+	sort.Strings(rs)
+	sort.Strings(cs)
+	key := fmt.Sprint(rs, cs, Crashed, Partitioned, DefaultFailureProbability, FailureProbabilities,
+		ClientWeights, MinWorstLatency, BatchTimeout, BatchSize, ArrivalRate,
+		DefaultProcessingDelay, DefaultFsyncDelay, ProcessingDelays, FsyncDelays)
+	if key != availabilityKey {
+		availabilityKey = key
+		availabilityPr.SetText(AvailabilityReport(t, selectedReplicas, selectedClients))
 	}
 }
 
+// Sensitivity analyses take a while, so Redraw only asks to update the
+// details of the selected client.
+func OutdateDetail(t *LatencyTable) {
+	if detailProtocol == nil || !contains(selectedClients, detailClient) {
+		detailPr.SetText("select a client row for details")
+		return
+	}
+	detailPr.SetText("press enter to update the details of " + t.Site(detailClient))
+}
+
+// Shows the sensitivity analysis of the client row selected in clientsInfoPr.
+func UpdateDetail(t *LatencyTable) {
+	if detailProtocol == nil || !contains(selectedClients, detailClient) {
+		detailPr.SetText("select a client row for details")
+		return
+	}
+	detailPr.SetText(SensitivityReport(t, detailProtocol.Alg, detailProtocol.TwoPaths, selectedReplicas, selectedClients, detailClient))
+	detailPr.ScrollToBeginning()
+}
+
 func Faster(g, l float64) float64 {
+	if math.IsInf(g, 1) {
+		if math.IsInf(l, 1) {
+			return 0
+		}
+		return 100
+	}
 	if g == 0 {
 		if l == 0 {
 			return 0
@@ -128,7 +242,17 @@ func Faster(g, l float64) float64 {
 	return Div(Mul((g-l), 100), g)
 }
 
-func UpdateClientInfo(leader string, quorum Quorum, alg Algorithm, t *LatencyTable, printWorstL, printClosest bool, compareTo []Algorithm) {
+// Formats latency `l` (ms) in 7 columns, "∞" if no command can commit.
+func FormatLatency(l float64) string {
+	if math.IsInf(l, 1) {
+		return "      ∞"
+	}
+	return fmt.Sprintf("%7.3f", l)
+}
+
+// If `baseline` is not nil, it maps clients to their latency without
+// crashed replicas, and the degradation due to crashes is printed.
+func UpdateClientInfo(leader string, quorum Quorum, alg Algorithm, t *LatencyTable, printWorstL, printClosest bool, compareTo []Algorithm, baseline map[string]float64) {
 	if quorum != nil {
 		quorumPr.SetText(fmt.Sprintf("%v", quorum))
 	} else {
@@ -136,21 +260,46 @@ func UpdateClientInfo(leader string, quorum Quorum, alg Algorithm, t *LatencyTab
 		quorumPr.SetText("N/A")
 	}
 	latency := Average(alg, selectedClients, true)
+	if leader == "" {
+		leader = "none"
+	}
 	leaderPr.SetText(fmt.Sprintf("%v", leader))
-	ls := fmt.Sprintf("%0.3f (fast)\n%0.3f (slow)", latency, Average(alg, selectedClients, false))
+	if len(Crashed) != 0 {
+		leaderPr.SetText(fmt.Sprintf("%v\n(crashed: %v)", leader, strings.Join(SliceOfQuorum(Crashed), ", ")))
+	}
+	if len(Partitioned) != 0 {
+		leaderPr.SetText(leaderPr.GetText(false) + fmt.Sprintf("\n(partition: %v)", Partitioned))
+	}
+	ls := fmt.Sprintf("%v (fast)\n%v (slow)", strings.TrimSpace(FormatLatency(latency)), strings.TrimSpace(FormatLatency(Average(alg, selectedClients, false))))
 	if !printWorstL {
-		ls = fmt.Sprintf("%0.3f", latency)
+		ls = strings.TrimSpace(FormatLatency(latency))
+	}
+	if math.IsInf(Average(alg, selectedClients, false), 1) {
+		ls += "\nsome clients cannot make progress"
+	} else if HasDelays() {
+		p, f := DelayContributions(alg, selectedClients, true)
+		ls += fmt.Sprintf("\nincl. %0.3f processing\nincl. %0.3f fsync", p, f)
+	}
+	if Batching() && leader != "<leaderless>" {
+		ls += fmt.Sprintf("\nbatch wait %0.3f", BatchWait())
 	}
 	latencyPr.SetText(ls)
 
 	ls = ""
 	longest := ""
+	clientRows = map[string]string{}
 	sort.Slice(selectedClients, func(i, j int) bool {
 		return selectedClients[i] < selectedClients[j]
 	})
+	label := func(c string) string {
+		if w := Weight(c); w != 1 {
+			return fmt.Sprintf("%v ×%v", c, w)
+		}
+		return c
+	}
 	for _, c := range selectedClients {
-		if utf8.RuneCountInString(c) > utf8.RuneCountInString(longest) {
-			longest = c
+		if utf8.RuneCountInString(label(c)) > utf8.RuneCountInString(longest) {
+			longest = label(c)
 		}
 	}
 	if compareTo != nil {
@@ -169,14 +318,16 @@ func UpdateClientInfo(leader string, quorum Quorum, alg Algorithm, t *LatencyTab
 		if ls != "" {
 			ls += "\n"
 		}
-		ls += c
-		for i := 0; i < utf8.RuneCountInString(longest)-utf8.RuneCountInString(c); i++ {
+		id := clientRow(c)
+		clientRows[id] = c
+		ls += fmt.Sprintf(`["%v"]%v[""]`, id, label(c))
+		for i := 0; i < utf8.RuneCountInString(longest)-utf8.RuneCountInString(label(c)); i++ {
 			ls += " "
 		}
 		if best != worst {
 			ls += "[#00BD56]"
 		}
-		ls += fmt.Sprintf("\t%7.3f[white]", best)
+		ls += "\t" + FormatLatency(best) + "[white]"
 
 		for _, a := range compareTo {
 			l := Average(a, []string{c}, true)
@@ -196,7 +347,7 @@ func UpdateClientInfo(leader string, quorum Quorum, alg Algorithm, t *LatencyTab
 			for range longest {
 				ls += " "
 			}
-			ls += fmt.Sprintf("\t[#FF424D]%7.3f[white]", worst)
+			ls += "\t[#FF424D]" + FormatLatency(worst) + "[white]"
 			for _, a := range compareTo {
 				l := Average(a, []string{c}, false)
 				if worst <= l {
@@ -215,7 +366,18 @@ func UpdateClientInfo(leader string, quorum Quorum, alg Algorithm, t *LatencyTab
 			for range longest {
 				ls += " "
 			}
-			ls += "\t[#668AAC]" + t.Site(Client(c).ClosestReplica(selectedReplicas, t)) + "[white]"
+			ls += "\t[#668AAC]" + t.Site(Client(c).ClosestReplica(ReachableFrom(c, selectedReplicas), t)) + "[white]"
+		}
+		if baseline != nil {
+			ls += "\n"
+			for range longest {
+				ls += " "
+			}
+			if d := best - baseline[c]; math.IsInf(best, 1) {
+				ls += "\t[red]no progress[white]"
+			} else {
+				ls += fmt.Sprintf("\t[#FFA500]%+7.3f[white] under failure", d)
+			}
 		}
 	}
 	clientsInfoPr.SetText(ls)
@@ -247,7 +409,7 @@ func NewExportBox(t *LatencyTable) {
 				for _, r := range selectedReplicas {
 					str += "server_alias " + t.IdOf(r) + "\n"
 					for _, c := range selectedClients {
-						ls := t.IdOf(Client(c).ClosestReplica(selectedReplicas, t))
+						ls := t.IdOf(Client(c).ClosestReplica(ReachableFrom(c, selectedReplicas), t))
 						if ls == t.IdOf(r) {
 							str += t.IdOf(c)
 							if ls == t.IdOf(c) {
@@ -287,7 +449,7 @@ func NewImportBox(t *LatencyTable) {
 		return tview.NewGrid().SetColumns(0, w, 0).SetRows(0, h, 0).AddItem(p, 1, 1, 1, 1, 0, 0, true)
 	}
 	var form *tview.Form
-	form = tview.NewForm().AddInputField("Filename", filename, 30, nil, func(f string) {
+	form = tview.NewForm().AddInputField("Source", filename, 30, nil, func(f string) {
 		filename = f
 		if form.GetFormItemCount() >= 2 {
 			form.RemoveFormItem(1)
@@ -299,10 +461,26 @@ func NewImportBox(t *LatencyTable) {
 	form.SetFieldBackgroundColor(tcell.ColorGrey)
 	form.AddButton("Import", func() {
 		if filename != "" {
-			if t, err := NewLatencyTableFromFile(filename); err == nil {
+			var nt *LatencyTable
+			src, err := ParseLatencySource(filename)
+			if err == nil {
+				nt, err = src.Load()
+			}
+			if err == nil {
+				err = Prepare(nt)
+			}
+			// crashes, partitions and weights name regions of the old
+			// table: start over from the command line
+			crashed, partitioned, weights := Crashed, Partitioned, ClientWeights
+			Crashed, Partitioned, ClientWeights = Quorum{}, Partition{}, map[string]float64{}
+			if err == nil {
+				err = Setup(nt)
+			}
+			if err == nil {
 				application.Stop()
-				defer RunUI(t)
+				defer RunUI(nt)
 			} else {
+				Crashed, Partitioned, ClientWeights = crashed, partitioned, weights
 				if form.GetFormItemCount() >= 2 {
 					form.RemoveFormItem(1)
 				}
@@ -316,8 +494,486 @@ func NewImportBox(t *LatencyTable) {
 	pages.AddPage("import box", modal(form, 40, 10), true, false)
 }
 
+func NewWeightsBox(t *LatencyTable) {
+	modal := func(p tview.Primitive, w, h int) tview.Primitive {
+		return tview.NewGrid().SetColumns(0, w, 0).SetRows(0, h, 0).AddItem(p, 1, 1, 1, 1, 0, 0, true)
+	}
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle("Client weights (request rates)")
+	form.SetLabelColor(tcell.ColorWhite)
+	form.SetFieldTextColor(tcell.ColorWhite)
+	form.SetFieldBackgroundColor(tcell.ColorGrey)
+	for _, c := range selectedClients {
+		cc := c
+		w := strconv.FormatFloat(Weight(c), 'f', -1, 64)
+		form.AddInputField(t.Site(c), w, 10, tview.InputFieldFloat, func(s string) {
+			if w, err := strconv.ParseFloat(s, 64); err == nil && w >= 0 {
+				ClientWeights[cc] = w
+			}
+		})
+	}
+	form.AddButton("OK", func() {
+		pages.SwitchToPage("main page")
+		Redraw(t)
+	})
+	form.AddButton("Reset", func() {
+		for _, c := range selectedClients {
+			delete(ClientWeights, c)
+		}
+		pages.SwitchToPage("main page")
+		Redraw(t)
+	})
+	pages.AddPage("weights box", modal(form, 50, len(selectedClients)+6), true, false)
+}
+
+func NewFailureBox(t *LatencyTable) {
+	modal := func(p tview.Primitive, w, h int) tview.Primitive {
+		return tview.NewGrid().SetColumns(0, w, 0).SetRows(0, h, 0).AddItem(p, 1, 1, 1, 1, 0, 0, true)
+	}
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle("Crashed replicas")
+	form.SetLabelColor(tcell.ColorWhite)
+	form.SetFieldTextColor(tcell.ColorWhite)
+	form.SetFieldBackgroundColor(tcell.ColorGrey)
+	for _, r := range selectedReplicas {
+		rr := r
+		form.AddCheckbox(t.Site(r), IsCrashed(r), func(checked bool) {
+			if checked {
+				Crashed[rr] = struct{}{}
+			} else {
+				delete(Crashed, rr)
+			}
+		})
+	}
+	form.AddButton("OK", func() {
+		pages.SwitchToPage("main page")
+		Redraw(t)
+	})
+	form.AddButton("Worst single failure", func() {
+		_, protocol := protocolPr.GetCurrentOption()
+		if r, _ := WorstSingleFailure(t, selectedReplicas, selectedClients, protocol); r != "" {
+			Crashed = QuorumOfSlice([]string{r})
+		}
+		pages.SwitchToPage("main page")
+		Redraw(t)
+	})
+	form.AddButton("None", func() {
+		Crashed = Quorum{}
+		pages.SwitchToPage("main page")
+		Redraw(t)
+	})
+	pages.AddPage("failure box", modal(form, 60, len(selectedReplicas)+6), true, false)
+}
+
+func NewBatchingBox(t *LatencyTable) {
+	modal := func(p tview.Primitive, w, h int) tview.Primitive {
+		return tview.NewGrid().SetColumns(0, w, 0).SetRows(0, h, 0).AddItem(p, 1, 1, 1, 1, 0, 0, true)
+	}
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle("Leader batching")
+	form.SetLabelColor(tcell.ColorWhite)
+	form.SetFieldTextColor(tcell.ColorWhite)
+	form.SetFieldBackgroundColor(tcell.ColorGrey)
+	form.AddInputField("batch timeout (ms)", strconv.FormatFloat(BatchTimeout, 'f', -1, 64), 10, tview.InputFieldFloat, func(s string) {
+		if v, err := strconv.ParseFloat(s, 64); err == nil && v >= 0 {
+			BatchTimeout = v
+		}
+	})
+	form.AddInputField("batch size", strconv.Itoa(BatchSize), 10, tview.InputFieldInteger, func(s string) {
+		if v, err := strconv.Atoi(s); err == nil && v >= 1 {
+			BatchSize = v
+		}
+	})
+	form.AddInputField("arrival rate (cmd/s)", strconv.FormatFloat(ArrivalRate, 'f', -1, 64), 10, tview.InputFieldFloat, func(s string) {
+		if v, err := strconv.ParseFloat(s, 64); err == nil && v >= 0 {
+			ArrivalRate = v
+		}
+	})
+	form.AddButton("OK", func() {
+		pages.SwitchToPage("main page")
+		Redraw(t)
+	})
+	form.AddButton("Disable", func() {
+		BatchSize = 1
+		pages.SwitchToPage("main page")
+		Redraw(t)
+	})
+	pages.AddPage("batching box", modal(form, 50, 11), true, false)
+}
+
+func NewFailureProbabilitiesBox(t *LatencyTable) {
+	modal := func(p tview.Primitive, w, h int) tview.Primitive {
+		return tview.NewGrid().SetColumns(0, w, 0).SetRows(0, h, 0).AddItem(p, 1, 1, 1, 1, 0, 0, true)
+	}
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle("Failure probabilities")
+	form.SetLabelColor(tcell.ColorWhite)
+	form.SetFieldTextColor(tcell.ColorWhite)
+	form.SetFieldBackgroundColor(tcell.ColorGrey)
+	probability := func(set func(float64)) func(string) {
+		return func(s string) {
+			if p, err := strconv.ParseFloat(s, 64); err == nil && p >= 0 && p <= 1 {
+				set(p)
+			}
+		}
+	}
+	form.AddInputField("default", strconv.FormatFloat(DefaultFailureProbability, 'f', -1, 64), 10, tview.InputFieldFloat, probability(func(p float64) {
+		DefaultFailureProbability = p
+	}))
+	for _, r := range selectedReplicas {
+		rr := r
+		p := ""
+		if _, exists := FailureProbabilities[r]; exists {
+			p = strconv.FormatFloat(FailureProbabilities[r], 'f', -1, 64)
+		}
+		form.AddInputField(t.Site(r), p, 10, tview.InputFieldFloat, func(s string) {
+			if s == "" {
+				delete(FailureProbabilities, rr)
+			}
+			probability(func(p float64) {
+				FailureProbabilities[rr] = p
+			})(s)
+		})
+	}
+	form.AddButton("OK", func() {
+		pages.SwitchToPage("main page")
+		Redraw(t)
+	})
+	form.AddButton("Reset", func() {
+		for _, r := range selectedReplicas {
+			delete(FailureProbabilities, r)
+		}
+		pages.SwitchToPage("main page")
+		Redraw(t)
+	})
+	pages.AddPage("probabilities box", modal(form, 50, len(selectedReplicas)+8), true, false)
+}
+
+func NewPartitionBox(t *LatencyTable) {
+	modal := func(p tview.Primitive, w, h int) tview.Primitive {
+		return tview.NewGrid().SetColumns(0, w, 0).SetRows(0, h, 0).AddItem(p, 1, 1, 1, 1, 0, 0, true)
+	}
+	spec := ""
+	for i, side := range Partitioned {
+		if i != 0 {
+			spec += " | "
+		}
+		var ids []string
+		for _, r := range SliceOfQuorum(side) {
+			ids = append(ids, t.IdOf(r))
+		}
+		sort.Strings(ids)
+		spec += strings.Join(ids, ",")
+	}
+	report := tview.NewTextView()
+	report.SetScrollable(true)
+	update := func() {
+		if len(selectedReplicas) == 0 || len(selectedClients) == 0 {
+			report.SetText("select replicas and clients first")
+			return
+		}
+		report.SetText(PartitionReport(t, selectedReplicas, selectedClients))
+		report.ScrollToBeginning()
+	}
+	update()
+
+	form := tview.NewForm()
+	form.SetLabelColor(tcell.ColorWhite)
+	form.SetFieldTextColor(tcell.ColorWhite)
+	form.SetFieldBackgroundColor(tcell.ColorGrey)
+	form.SetButtonsAlign(tview.AlignCenter)
+	form.AddInputField("Sides (a,b | c)", spec, 50, nil, func(s string) {
+		spec = s
+	})
+	form.AddButton("Apply", func() {
+		ps, err := t.ParsePartitions(spec)
+		if err != nil {
+			report.SetText(err.Error())
+			return
+		}
+		Partitioned = Partition{}
+		if len(ps) != 0 {
+			Partitioned = ps[0]
+		}
+		update()
+		Redraw(t)
+	})
+	form.AddButton("None", func() {
+		Partitioned = Partition{}
+		pages.SwitchToPage("main page")
+		Redraw(t)
+	})
+	form.AddButton("OK", func() {
+		pages.SwitchToPage("main page")
+	})
+
+	f := tview.NewFlex().SetDirection(tview.FlexRow)
+	f.AddItem(report, 0, 1, false)
+	f.AddItem(form, 5, 0, true)
+	f.SetBorder(true).SetTitle("Network partition")
+	pages.AddPage("partition box", modal(f, 80, 30), true, false)
+}
+
+func NewFlowBox(t *LatencyTable) {
+	modal := func(p tview.Primitive, w, h int) tview.Primitive {
+		return tview.NewGrid().SetColumns(0, w, 0).SetRows(0, h, 0).AddItem(p, 1, 1, 1, 1, 0, 0, true)
+	}
+	filename := "flow.svg"
+	fast := true
+	diagram := tview.NewTextView()
+	diagram.SetDynamicColors(true)
+	diagram.SetScrollable(true)
+	diagram.SetWrap(false)
+	form := tview.NewForm()
+	form.SetLabelColor(tcell.ColorWhite)
+	form.SetFieldTextColor(tcell.ColorWhite)
+	form.SetFieldBackgroundColor(tcell.ColorGrey)
+	form.SetButtonsAlign(tview.AlignCenter)
+
+	var (
+		alg Algorithm
+		c   = detailClient
+	)
+	update := func() {
+		if alg == nil {
+			diagram.SetText("select a client row first")
+			return
+		}
+		diagram.SetText(FlowDiagram(t, alg, c, fast, alg.Flow(c, fast), true))
+		diagram.ScrollToBeginning()
+	}
+	if detailProtocol != nil && contains(selectedClients, c) {
+		alg = detailProtocol.Alg
+		if detailProtocol.TwoPaths {
+			form.AddCheckbox("slow path", false, func(checked bool) {
+				fast = !checked
+				update()
+			})
+		}
+	}
+	update()
+	form.AddInputField("Save as", filename, 20, nil, func(f string) {
+		filename = f
+	})
+	form.AddButton("Export", func() {
+		if alg == nil || filename == "" {
+			return
+		}
+		if err := ExportFlow(t, alg, c, fast, alg.Flow(c, fast), filename); err != nil {
+			diagram.SetText(err.Error())
+			return
+		}
+		pages.SwitchToPage("main page")
+	})
+	form.AddButton("Close", func() {
+		pages.SwitchToPage("main page")
+	})
+
+	f := tview.NewFlex().SetDirection(tview.FlexRow)
+	f.AddItem(diagram, 0, 1, false)
+	f.AddItem(form, 7, 0, true)
+	f.SetBorder(true).SetTitle("Message flow (.svg, .mmd, .md or text export)")
+	pages.AddPage("flow box", modal(f, 110, 35), true, false)
+}
+
+func NewChartsBox(t *LatencyTable) {
+	modal := func(p tview.Primitive, w, h int) tview.Primitive {
+		return tview.NewGrid().SetColumns(0, w, 0).SetRows(0, h, 0).AddItem(p, 1, 1, 1, 1, 0, 0, true)
+	}
+	dir := "charts"
+	_, base := protocolPr.GetCurrentOption()
+	info := tview.NewTextView()
+	if len(selectedReplicas) == 0 || len(selectedClients) == 0 {
+		info.SetText("select replicas and clients first")
+	} else {
+		info.SetText("bars, cdf and speedup (over " + base + ") SVG charts of fast and slow paths")
+	}
+	form := tview.NewForm()
+	form.SetLabelColor(tcell.ColorWhite)
+	form.SetFieldTextColor(tcell.ColorWhite)
+	form.SetFieldBackgroundColor(tcell.ColorGrey)
+	form.SetButtonsAlign(tview.AlignCenter)
+	form.AddInputField("Directory", dir, 30, nil, func(d string) {
+		dir = d
+	})
+	form.AddButton("Export", func() {
+		if len(selectedReplicas) == 0 || len(selectedClients) == 0 || dir == "" {
+			return
+		}
+		if err := ExportCharts(t, selectedReplicas, selectedClients, base, dir); err != nil {
+			info.SetText(err.Error())
+			return
+		}
+		pages.SwitchToPage("main page")
+	})
+	form.AddButton("Cancel", func() {
+		pages.SwitchToPage("main page")
+	})
+
+	f := tview.NewFlex().SetDirection(tview.FlexRow)
+	f.AddItem(info, 2, 0, false)
+	f.AddItem(form, 0, 1, true)
+	f.SetBorder(true).SetTitle("Charts")
+	pages.AddPage("charts box", modal(f, 60, 10), true, false)
+}
+
+func NewTableBox(t *LatencyTable) {
+	modal := func(p tview.Primitive, w, h int) tview.Primitive {
+		return tview.NewGrid().SetColumns(0, w, 0).SetRows(0, h, 0).AddItem(p, 1, 1, 1, 1, 0, 0, true)
+	}
+	filename := "comparison.tex"
+	rows := "both"
+	sites := true
+	_, protocol := protocolPr.GetCurrentOption()
+	info := tview.NewTextView()
+	if len(selectedReplicas) == 0 || len(selectedClients) == 0 {
+		info.SetText("select replicas and clients first")
+	} else {
+		info.SetText(protocol + " compared with other protocols (.tex or .md)")
+	}
+	form := tview.NewForm()
+	form.SetLabelColor(tcell.ColorWhite)
+	form.SetFieldTextColor(tcell.ColorWhite)
+	form.SetFieldBackgroundColor(tcell.ColorGrey)
+	form.SetButtonsAlign(tview.AlignCenter)
+	form.AddDropDown("Rows", []string{"both", "fast", "slow"}, 0, func(option string, _ int) {
+		rows = option
+	})
+	form.AddCheckbox("Site names", sites, func(checked bool) {
+		sites = checked
+	})
+	form.AddInputField("Save as", filename, 30, nil, func(f string) {
+		filename = f
+	})
+	form.AddButton("Export", func() {
+		if len(selectedReplicas) == 0 || len(selectedClients) == 0 || filename == "" {
+			return
+		}
+		if err := ExportComparison(t, selectedReplicas, selectedClients, protocol, rows, sites, filename); err != nil {
+			info.SetText(err.Error())
+			return
+		}
+		pages.SwitchToPage("main page")
+	})
+	form.AddButton("Cancel", func() {
+		pages.SwitchToPage("main page")
+	})
+
+	f := tview.NewFlex().SetDirection(tview.FlexRow)
+	f.AddItem(info, 2, 0, false)
+	f.AddItem(form, 0, 1, true)
+	f.SetBorder(true).SetTitle("Comparison table")
+	pages.AddPage("table box", modal(f, 60, 14), true, false)
+}
+
+func NewFailoverBox(t *LatencyTable) {
+	modal := func(p tview.Primitive, w, h int) tview.Primitive {
+		return tview.NewGrid().SetColumns(0, w, 0).SetRows(0, h, 0).AddItem(p, 1, 1, 1, 1, 0, 0, true)
+	}
+	report := tview.NewTextView()
+	report.SetScrollable(true)
+	if len(selectedReplicas) == 0 || len(selectedClients) == 0 {
+		report.SetText("select replicas and clients first")
+	} else {
+		report.SetText(FailoverReport(t, selectedReplicas, selectedClients))
+	}
+
+	form := tview.NewForm()
+	form.SetButtonsAlign(tview.AlignCenter)
+	form.AddButton("OK", func() {
+		pages.SwitchToPage("main page")
+	})
+
+	f := tview.NewFlex().SetDirection(tview.FlexRow)
+	f.AddItem(report, 0, 1, false)
+	f.AddItem(form, 3, 0, true)
+	f.SetBorder(true).SetTitle("Leader failover")
+	pages.AddPage("failover box", modal(f, 50, 30), true, false)
+}
+
+func NewThroughputBox(t *LatencyTable) {
+	modal := func(p tview.Primitive, w, h int) tview.Primitive {
+		return tview.NewGrid().SetColumns(0, w, 0).SetRows(0, h, 0).AddItem(p, 1, 1, 1, 1, 0, 0, true)
+	}
+	report := tview.NewTextView()
+	report.SetScrollable(true)
+	if len(selectedReplicas) == 0 || len(selectedClients) == 0 {
+		report.SetText("select replicas and clients first")
+	} else {
+		report.SetText(ThroughputReport(t, selectedReplicas, selectedClients))
+	}
+
+	form := tview.NewForm()
+	form.SetButtonsAlign(tview.AlignCenter)
+	form.AddButton("OK", func() {
+		pages.SwitchToPage("main page")
+	})
+
+	f := tview.NewFlex().SetDirection(tview.FlexRow)
+	f.AddItem(report, 0, 1, false)
+	f.AddItem(form, 3, 0, true)
+	f.SetBorder(true).SetTitle("Throughput")
+	pages.AddPage("throughput box", modal(f, 110, 12), true, false)
+}
+
+func NewValidationBox(t *LatencyTable) bool {
+	is := t.Validate()
+	if len(Errors(is)) == 0 {
+		return false
+	}
+
+	modal := func(p tview.Primitive, w, h int) tview.Primitive {
+		return tview.NewGrid().SetColumns(0, w, 0).SetRows(0, h, 0).AddItem(p, 1, 1, 1, 1, 0, 0, true)
+	}
+	report := tview.NewTextView()
+	report.SetScrollable(true)
+	update := func(filled int) {
+		text := fmt.Sprintf("%d issue(s) found in the latency table:\n\n", len(is)) + t.Report(is)
+		if filled > 0 {
+			text = fmt.Sprintf("%d link(s) filled\n\n", filled) + text
+		}
+		if len(Errors(is)) == 0 {
+			text = fmt.Sprintf("%d link(s) filled, no issues left", filled)
+		}
+		report.SetText(text)
+		report.ScrollToBeginning()
+	}
+	update(0)
+
+	form := tview.NewForm()
+	form.SetLabelColor(tcell.ColorWhite)
+	form.SetFieldTextColor(tcell.ColorWhite)
+	form.SetFieldBackgroundColor(tcell.ColorGrey)
+	form.SetButtonsAlign(tview.AlignCenter)
+	repair := func(mode RepairMode) func() {
+		return func() {
+			n := t.Repair(mode)
+			is = t.Validate()
+			update(n)
+			availabilityKey = ""
+			Redraw(t)
+		}
+	}
+	form.AddButton("Continue", func() {
+		pages.SwitchToPage("main page")
+	})
+	form.AddButton("Fill symmetric", repair(RepairSymmetric))
+	form.AddButton("Fill shortest path", repair(RepairShortestPath))
+	form.AddButton("Estimate from coordinates", repair(RepairCoordinates))
+	form.AddButton("Abort", func() {
+		application.Stop()
+	})
+
+	f := tview.NewFlex().SetDirection(tview.FlexRow)
+	f.AddItem(report, 0, 1, false)
+	f.AddItem(form, 3, 0, true)
+	f.SetBorder(true).SetTitle("Latency table validation")
+	pages.AddPage("validation box", modal(f, 80, 20), true, false)
+	return true
+}
+
 func NewReplicaClientSelections(t *LatencyTable) *tview.Flex {
-	rs := Regions("Replicas", t, func(rs map[string]struct{}) {
+	rs := Regions("Replicas", t, false, func(rs map[string]struct{}) {
 		i := 0
 		selectedReplicas = make([]string, len(rs))
 		for r := range rs {
@@ -326,7 +982,7 @@ func NewReplicaClientSelections(t *LatencyTable) *tview.Flex {
 		}
 		Redraw(t)
 	})
-	cs := Regions("Clients", t, func(rs map[string]struct{}) {
+	cs := Regions("Clients", t, true, func(rs map[string]struct{}) {
 		i := 0
 		selectedClients = make([]string, len(rs))
 		for r := range rs {
@@ -382,6 +1038,12 @@ func NewReplicaClientSelections(t *LatencyTable) *tview.Flex {
 	dw := tview.NewFlex()
 	dw.AddItem(d, 0, 1, false)
 	dw.AddItem(worstL, 0, 1, false)
+	if age := t.Age(); age != "" {
+		a := tview.NewTextView()
+		a.SetText("cloudping data fetched " + age)
+		a.SetTextAlign(tview.AlignRight)
+		dw.AddItem(a, 0, 1, false)
+	}
 
 	f2.AddItem(dw, 0, 1, false)
 
@@ -406,11 +1068,60 @@ func NewReplicaClientSelections(t *LatencyTable) *tview.Flex {
 	clientsInfoPr = newTextView("clients")
 	clientsInfoPr.SetLabel("")
 	clientsInfoPr.SetDynamicColors(true).SetRegions(true).SetBorder(true)
+	clientsInfoPr.SetHighlightedFunc(func(added, _, _ []string) {
+		if len(added) != 0 {
+			detailClient = clientRows[added[0]]
+			UpdateDetail(t)
+		}
+	})
+	// up and down keys move the selected client row, enter updates its details
+	clientsInfoPr.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEnter {
+			UpdateDetail(t)
+			return nil
+		}
+		if event.Key() != tcell.KeyUp && event.Key() != tcell.KeyDown || len(selectedClients) == 0 {
+			return event
+		}
+		i := -1
+		if hs := clientsInfoPr.GetHighlights(); len(hs) != 0 {
+			for j, c := range selectedClients {
+				if clientRow(c) == hs[0] {
+					i = j
+				}
+			}
+		}
+		if event.Key() == tcell.KeyUp {
+			i--
+		} else {
+			i++
+		}
+		i = (i + len(selectedClients)) % len(selectedClients)
+		clientsInfoPr.Highlight(clientRow(selectedClients[i])).ScrollToHighlight()
+		return nil
+	})
+	detailPr = newTextView("details")
+	detailPr.SetLabel("")
+	detailPr.SetScrollable(true)
+	detailPr.SetBorder(true).SetTitle("Details").SetTitleAlign(tview.AlignLeft)
+	f6 := tview.NewFlex()
+	f6.AddItem(clientsInfoPr, 0, 3, false)
+	f6.AddItem(detailPr, 0, 2, false)
 	f4 := tview.NewFlex()
 	f4.SetBorder(true)
 	f4.SetDirection(tview.FlexRow)
+	availabilityPr = newTextView("availability")
+	availabilityPr.SetLabel("")
+	availabilityPr.SetBorder(true).SetTitle("Availability").SetTitleAlign(tview.AlignLeft)
+	costPr = newTextView("cost")
+	costPr.SetLabel("")
+	costPr.SetBorder(true).SetTitle("Traffic").SetTitleAlign(tview.AlignLeft)
+	f5 := tview.NewFlex()
+	f5.AddItem(availabilityPr, 0, 3, false)
+	f5.AddItem(costPr, 0, 2, false)
 	f4.AddItem(f3, 0, 1, false)
-	f4.AddItem(clientsInfoPr, 0, 3, false)
+	f4.AddItem(f5, 8, 0, false)
+	f4.AddItem(f6, 0, 3, false)
 
 	f2.AddItem(f4, 0, 12, false)
 
@@ -426,10 +1137,13 @@ func RunUI(t *LatencyTable) error {
 	pages = tview.NewPages().AddPage("main page", s, true, true)
 	NewExportBox(t)
 	NewImportBox(t)
+	if NewValidationBox(t) {
+		pages.ShowPage("validation box")
+	}
 	application = tview.NewApplication().SetRoot(pages, true).EnableMouse(true)
 
 	i := 0
-	ps := []tview.Primitive{replicasPr, clientsPr, protocolPr}
+	ps := []tview.Primitive{replicasPr, clientsPr, protocolPr, clientsInfoPr}
 
 	shown := false
 	lt := tview.NewTextView()
@@ -437,6 +1151,9 @@ func RunUI(t *LatencyTable) error {
 		application.Draw()
 	})
 	lt.SetLabel("Latency Table ")
+	if age := t.Age(); age != "" {
+		lt.SetLabel("Latency Table (fetched " + age + ") ")
+	}
 	lt.SetText(t.String())
 
 	s.SetMouseCapture(func(a tview.MouseAction, e *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
@@ -470,6 +1187,36 @@ func RunUI(t *LatencyTable) error {
 			pages.ShowPage("export box")
 		case 'i':
 			pages.ShowPage("import box")
+		case 'w':
+			NewWeightsBox(t)
+			pages.ShowPage("weights box")
+		case 'f':
+			NewFailureBox(t)
+			pages.ShowPage("failure box")
+		case 'b':
+			NewBatchingBox(t)
+			pages.ShowPage("batching box")
+		case 'a':
+			NewFailureProbabilitiesBox(t)
+			pages.ShowPage("probabilities box")
+		case 'n':
+			NewPartitionBox(t)
+			pages.ShowPage("partition box")
+		case 'd':
+			NewFlowBox(t)
+			pages.ShowPage("flow box")
+		case 'x':
+			NewTableBox(t)
+			pages.ShowPage("table box")
+		case 'g':
+			NewChartsBox(t)
+			pages.ShowPage("charts box")
+		case 't':
+			NewThroughputBox(t)
+			pages.ShowPage("throughput box")
+		case 'l':
+			NewFailoverBox(t)
+			pages.ShowPage("failover box")
 		case 'r':
 			application.SetFocus(replicasPr)
 		case 'c':
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+type IssueKind int
+
+const (
+	// no latency in either direction
+	MissingLink IssueKind = iota
+	// latency known in one direction only
+	OneWayLink
+	// latencies in both directions differ by more than the threshold
+	AsymmetricLink
+	// a direct link is slower than a detour through another region
+	TriangleViolation
+	// latency from a region to itself is not zero (a warning, since
+	// cloudping measures latencies between zones of the same region)
+	SelfLatency
+)
+
+type Issue struct {
+	Kind   IssueKind
+	R1, R2 string
+	// detour region of TriangleViolation
+	Via string
+	// latencies r1->r2 and r2->r1 (or r1->r2 and r1->via->r2)
+	L1, L2 float64
+}
+
+var (
+	// relative difference tolerated between both directions of a link
+	AsymmetryThreshold = 0.2
+	// relative slack tolerated before reporting a triangle violation
+	TriangleSlack = 0.1
+)
+
+// Warnings are reported, but do not prevent using the latency table.
+func (i Issue) Warning() bool {
+	return i.Kind == SelfLatency
+}
+
+// Returns the issues of `is` that are not warnings.
+func Errors(is []Issue) []Issue {
+	var es []Issue
+	for _, i := range is {
+		if !i.Warning() {
+			es = append(es, i)
+		}
+	}
+	return es
+}
+
+func (t *LatencyTable) Validate() []Issue {
+	var is []Issue
+
+	for i, r1 := range t.regions {
+		if l, exists := t.latency[r1][r1]; exists && l != 0 {
+			is = append(is, Issue{Kind: SelfLatency, R1: r1, R2: r1, L1: l})
+		}
+		for _, r2 := range t.regions[i+1:] {
+			if t.IsSite(r1) && t.IsSite(r2) {
+				continue
+			}
+			l1, exists1 := t.latency[r1][r2]
+			l2, exists2 := t.latency[r2][r1]
+			switch {
+			case !exists1 && !exists2:
+				is = append(is, Issue{Kind: MissingLink, R1: r1, R2: r2})
+			case !exists1:
+				is = append(is, Issue{Kind: OneWayLink, R1: r2, R2: r1, L1: l2})
+			case !exists2:
+				is = append(is, Issue{Kind: OneWayLink, R1: r1, R2: r2, L1: l1})
+			case math.Abs(l1-l2) > AsymmetryThreshold*math.Max(l1, l2):
+				is = append(is, Issue{Kind: AsymmetricLink, R1: r1, R2: r2, L1: l1, L2: l2})
+			}
+		}
+	}
+
+	for i, r1 := range t.regions {
+		for _, r2 := range t.regions[i+1:] {
+			if !t.HasLink(r1, r2) {
+				continue
+			}
+			direct := 2 * t.oneWay(r1, r2)
+			via, detour := "", math.Inf(1)
+			for _, r3 := range t.regions {
+				if r3 == r1 || r3 == r2 || t.IsSite(r3) || !t.HasLink(r1, r3) || !t.HasLink(r3, r2) {
+					continue
+				}
+				if l := 2*t.oneWay(r1, r3) + 2*t.oneWay(r3, r2); l < detour {
+					via, detour = r3, l
+				}
+			}
+			if via != "" && direct > detour*(1+TriangleSlack) {
+				is = append(is, Issue{Kind: TriangleViolation, R1: r1, R2: r2, Via: via, L1: direct, L2: detour})
+			}
+		}
+	}
+
+	sort.SliceStable(is, func(i, j int) bool {
+		return is[i].Kind < is[j].Kind
+	})
+	return is
+}
+
+func (t *LatencyTable) IssueString(i Issue) string {
+	r1, r2 := t.IdOf(i.R1), t.IdOf(i.R2)
+	switch i.Kind {
+	case MissingLink:
+		return fmt.Sprintf("missing link %v <-> %v", r1, r2)
+	case OneWayLink:
+		return fmt.Sprintf("only %v -> %v is known (%vms)", r1, r2, FormatMs(i.L1))
+	case AsymmetricLink:
+		return fmt.Sprintf("asymmetric link %v -> %v %vms, back %vms", r1, r2, FormatMs(i.L1), FormatMs(i.L2))
+	case TriangleViolation:
+		return fmt.Sprintf("%v <-> %v takes %vms, but %vms via %v", r1, r2, FormatMs(i.L1), FormatMs(i.L2), t.IdOf(i.Via))
+	case SelfLatency:
+		return fmt.Sprintf("warning: self latency of %v is %vms", r1, FormatMs(i.L1))
+	}
+	return "unknown issue"
+}
+
+// Returns a human-readable validation report, empty if there are no issues.
+func (t *LatencyTable) Report(is []Issue) string {
+	s := ""
+	for _, i := range is {
+		s += t.IssueString(i) + "\n"
+	}
+	return s
+}
+
+type RepairMode int
+
+const (
+	RepairNone RepairMode = iota
+	// copy the known direction of one-way links
+	RepairSymmetric
+	// RepairSymmetric and estimate missing links by shortest paths
+	RepairShortestPath
+	// estimate missing links from region coordinates
+	RepairCoordinates
+)
+
+func ParseRepairMode(s string) (RepairMode, bool) {
+	switch s {
+	case "", "none":
+		return RepairNone, true
+	case "symmetric":
+		return RepairSymmetric, true
+	case "shortest":
+		return RepairShortestPath, true
+	case "coordinates":
+		return RepairCoordinates, true
+	}
+	return RepairNone, false
+}
+
+// Fills gaps of the latency table and returns the number of added links.
+func (t *LatencyTable) Repair(mode RepairMode) int {
+	if mode == RepairNone {
+		return 0
+	} else if mode == RepairCoordinates {
+		return t.Estimate()
+	}
+
+	n := 0
+	for _, r1 := range t.regions {
+		for _, r2 := range t.regions {
+			if r1 == r2 {
+				continue
+			}
+			if _, exists := t.latency[r1][r2]; exists {
+				continue
+			}
+			if l, exists := t.latency[r2][r1]; exists {
+				t.latency[r1][r2] = l
+				n++
+			}
+		}
+	}
+	if mode == RepairSymmetric {
+		return n
+	}
+
+	// Floyd-Warshall over round-trip latencies
+	d := make(map[string]map[string]float64, len(t.regions))
+	for _, r1 := range t.regions {
+		d[r1] = make(map[string]float64, len(t.regions))
+		for _, r2 := range t.regions {
+			d[r1][r2] = math.Inf(1)
+			if r1 == r2 {
+				d[r1][r2] = 0
+			} else if t.HasLink(r1, r2) {
+				d[r1][r2] = 2 * t.oneWay(r1, r2)
+			}
+		}
+	}
+	for _, k := range t.regions {
+		// sites only relay their own traffic
+		if t.IsSite(k) {
+			continue
+		}
+		for _, i := range t.regions {
+			for _, j := range t.regions {
+				if l := d[i][k] + d[k][j]; l < d[i][j] {
+					d[i][j] = l
+				}
+			}
+		}
+	}
+	for _, r1 := range t.regions {
+		for _, r2 := range t.regions {
+			if t.IsSite(r1) && t.IsSite(r2) {
+				continue
+			}
+			if r1 != r2 && !t.HasLink(r1, r2) && !math.IsInf(d[r1][r2], 1) {
+				t.latency[r1][r2] = d[r1][r2]
+				t.latency[r2][r1] = d[r1][r2]
+				t.estimated[link{r1, r2}] = struct{}{}
+				t.estimated[link{r2, r1}] = struct{}{}
+				n += 2
+			}
+		}
+	}
+	return n
+}